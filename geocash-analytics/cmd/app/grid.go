@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"geocash/internal/analytics"
+	"geocash/internal/config"
+	"geocash/internal/domain/city"
+	"geocash/internal/platform/postgres"
+)
+
+const gridUsage = "использование: geocash grid generate [--prune]"
+
+// runGrid - подкоманда `geocash grid generate [--prune]`: зоны трафика из той же
+// гексагональной сетки (bbox городов, grid.cell_radius), что и тепловая карта
+func runGrid(db *sql.DB, cfg config.Config, cities *city.Registry, args []string) error {
+	if len(args) == 0 || args[0] != "generate" {
+		return errors.New(gridUsage)
+	}
+
+	fs := flag.NewFlagSet("grid generate", flag.ContinueOnError)
+	prune := fs.Bool("prune", false, "удалить зоны сетки, которых нет в текущей конфигурации")
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%w: %s", err, gridUsage)
+	}
+	if fs.NArg() > 0 {
+		return errors.New(gridUsage)
+	}
+
+	gridSvc := analytics.NewGridService(cfg.Grid)
+	var cells []analytics.HexCell
+	for _, c := range cities.All() {
+		cityCells := gridSvc.Cells(c)
+		fmt.Printf("🔷 %s: %d ячеек (радиус %g°, bbox %g,%g - %g,%g)\n", c.Name, len(cityCells), cfg.Grid.CellRadius,
+			c.BBox.MinLat, c.BBox.MinLng, c.BBox.MaxLat, c.BBox.MaxLng)
+		cells = append(cells, cityCells...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	stats, err := postgres.NewZoneRepository(db).SyncGrid(ctx, cells, *prune)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Зоны сетки: добавлено %d, обновлено %d, удалено %d\n", stats.Inserted, stats.Updated, stats.Removed)
+	if stats.Inserted+stats.Updated > 0 {
+		fmt.Println("ℹ️ Трафик новых и измененных зон появится после импорта: geocash import traffic --force")
+	}
+	return nil
+}
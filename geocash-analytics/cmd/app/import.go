@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+
+	"geocash/internal/config"
+	"geocash/internal/dashboard"
+	"geocash/internal/domain/city"
+	"geocash/internal/domain/traffic"
+	"geocash/internal/geo"
+	"geocash/internal/ingest"
+	"geocash/internal/platform/memory"
+	"geocash/internal/platform/postgres"
+)
+
+const importUsage = "использование: geocash import traffic [--force] [путь к CSV]"
+
+// runImport - подкоманда `geocash import traffic [--force] [path]`; грузит только в Postgres
+func runImport(db *sql.DB, cfg config.Config, cities *city.Registry, args []string) error {
+	if len(args) == 0 || args[0] != ingest.KindTraffic {
+		return errors.New(importUsage)
+	}
+	// Зоны в памяти живут, пока работает процесс: загруженное командой пропало бы при выходе
+	if cfg.Traffic.Backend == config.TrafficBackendMemory {
+		return errors.New("при traffic.backend: memory трафик хранится только в памяти сервера: " +
+			"положите файл в traffic.csv_path перед стартом или загрузите его через POST /api/v1/imports/traffic")
+	}
+
+	fs := flag.NewFlagSet("import traffic", flag.ContinueOnError)
+	force := fs.Bool("force", cfg.Traffic.ForceReimport, "загрузить файл, даже если он уже импортирован")
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%w: %s", err, importUsage)
+	}
+	path := cfg.Traffic.CSVPath
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		path = fs.Arg(0)
+	default:
+		return errors.New(importUsage)
+	}
+
+	_, err := importTraffic(newTrafficBackend(db, cfg, cities).pipeline(cfg, cities), cfg, path, *force)
+	if errors.Is(err, ingest.ErrAlreadyImported) {
+		return nil
+	}
+	return err
+}
+
+// importTraffic потоково читает и проверяет CSV, заливает сегменты в хранилище
+// зон и записывает запуск в историю импортов
+func importTraffic(pipeline *ingest.TrafficPipeline, cfg config.Config, path string, force bool) (ingest.ImportRun, error) {
+	// Используем таймаут для безопасности
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Traffic.Timeout)
+	defer cancel()
+
+	run, err := pipeline.ImportFile(ctx, path, force, nil)
+	stats := run.Stats
+	switch {
+	case errors.Is(err, ingest.ErrAlreadyImported):
+		fmt.Printf("⏭️ %s уже импортирован (%v), пропускаем. Для повторной загрузки: --force или TRAFFIC_FORCE_REIMPORT=true\n", path, err)
+		return run, err
+	case err != nil:
+		if stats.RejectsFile != "" {
+			fmt.Printf("⚠️ Отброшено строк: %d, подробности в %s\n", stats.SegmentsRejected, stats.RejectsFile)
+		}
+		log.Printf("❌ Ошибка импорта трафика (запуск #%d): %v", run.ID, err)
+		return run, err
+	}
+
+	if stats.RejectsFile != "" {
+		fmt.Printf("⚠️ Отброшено строк: %d, подробности в %s\n", stats.SegmentsRejected, stats.RejectsFile)
+	}
+	fmt.Printf("🚀 Успех! Запуск #%d: прочитано %d, отброшено %d, в зонах %d сегментов; обновлено зон %d.\n",
+		run.ID, stats.SegmentsRead, stats.SegmentsRejected, stats.SegmentsMatched, stats.ZonesUpdated)
+	return run, nil
+}
+
+// trafficBackend - куда грузится трафик и откуда его читает аналитика
+// (traffic.backend: postgres или memory)
+type trafficBackend struct {
+	importer ingest.TrafficImporter
+	runs     ingest.RunStore
+	zones    traffic.ZoneRepository
+	// terminals - куда передавать наши банкоматы; nil - Postgres берет их из terminals
+	terminals dashboard.TerminalIndex
+}
+
+// newTrafficBackend: для memory БД не нужна (db может быть nil)
+func newTrafficBackend(db *sql.DB, cfg config.Config, cities *city.Registry) trafficBackend {
+	if cfg.Traffic.Backend == config.TrafficBackendMemory {
+		// Зоны читаются из того же хранилища, куда грузится трафик
+		store := memory.NewGridZoneStore(cfg.Grid, cities.All(), cfg.Traffic.ScoreMethod)
+		return trafficBackend{importer: store, runs: memory.NewRunStore(), zones: store, terminals: store}
+	}
+	return trafficBackend{
+		importer: postgres.NewTrafficIntegrator(db, cfg.Traffic),
+		runs:     postgres.NewImportRunRepository(db),
+		zones:    postgres.NewZoneRepository(db),
+	}
+}
+
+// pipeline - CSV -> проверка -> загрузка в хранилище зон, с историей импортов
+func (b trafficBackend) pipeline(cfg config.Config, cities *city.Registry) *ingest.TrafficPipeline {
+	var areas []geo.Bounds
+	for _, c := range cities.All() {
+		areas = append(areas, c.Bounds())
+	}
+	return ingest.NewTrafficPipeline(b.importer, b.runs, cfg.Traffic, areas)
+}
@@ -7,111 +7,262 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"time"
+	"path/filepath"
 
 	// Драйвер для Postgres
 	_ "github.com/lib/pq"
+	// База часовых поясов городов (в контейнере может не быть /usr/share/zoneinfo)
+	_ "time/tzdata"
 
 	"geocash/internal/analytics"
+	"geocash/internal/api"
+	"geocash/internal/config"
 	"geocash/internal/dashboard"
+	"geocash/internal/domain/brand"
+	"geocash/internal/domain/city"
 	"geocash/internal/domain/terminal"
+	"geocash/internal/domain/traffic"
+	"geocash/internal/ingest"
+	"geocash/internal/openinghours"
 	"geocash/internal/platform/loader"
 	"geocash/internal/platform/postgres"
-	"geocash/internal/platform/provider"
 )
 
 func main() {
-	// --- 1. ПОДКЛЮЧЕНИЕ К БАЗЕ ДАННЫХ ---
-	// Берем настройки из переменных окружения или ставим дефолтные для localhost
-	dbHost := getEnv("DB_HOST", "localhost")
-	dbPort := getEnv("DB_PORT", "5432")
-	dbUser := getEnv("DB_USER", "postgres")
-	dbPass := getEnv("DB_PASSWORD", "secret")
-	dbName := getEnv("DB_NAME", "atm_db")
-
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		dbUser, dbPass, dbHost, dbPort, dbName)
-
-	fmt.Println("🔌 Подключение к БД...", connStr)
-	db, err := sql.Open("postgres", connStr)
+	// --- 0. КОНФИГУРАЦИЯ ---
+	// YAML из CONFIG_PATH (или config/config.yaml) + переопределения из ENV
+	cfg, err := config.Load("")
 	if err != nil {
-		log.Fatalf("Ошибка открытия соединения с БД: %v", err)
+		log.Fatalf("❌ %v", err)
 	}
-	defer db.Close()
 
-	// Проверяем пинг
-	if err := db.Ping(); err != nil {
-		log.Fatalf("❌ БД недоступна: %v", err)
+	// Справочник городов: общий для OSM, сетки, импорта трафика и API
+	cities, err := city.NewRegistry(cfg)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
 	}
-	fmt.Println("✅ Успешное подключение к Postgres!")
 
-	// --- 2. ИМПОРТ CSV (ТРАФИК) ---
-	csvPath := "./traffic_data.csv"
-	if _, err := os.Stat(csvPath); err == nil {
-		fmt.Println("📂 Найден CSV файл, начинаем интеграцию...")
+	// --- 1. ПОДКЛЮЧЕНИЕ К БАЗЕ ДАННЫХ ---
+	// В демо-режиме с трафиком в памяти можно работать и без Postgres
+	dbOptional := cfg.DemoMode && cfg.Traffic.Backend == config.TrafficBackendMemory
+	db, err := openDB(cfg.DB)
+	switch {
+	case err == nil:
+		defer db.Close()
+		fmt.Println("✅ Успешное подключение к Postgres!")
+	case dbOptional:
+		fmt.Printf("⚠️ БД недоступна (%v), работаем без нее: трафик в памяти, данные терминалов случайные\n", err)
+		db = nil
+	default:
+		log.Fatalf("❌ БД недоступна: %v", err)
+	}
 
-		// 1. Парсим CSV
-		data, err := loader.LoadTrafficCSV(csvPath)
-		if err != nil {
-			log.Printf("❌ Ошибка чтения CSV: %v", err)
-		} else {
-			fmt.Printf("📊 Прочитано %d сегментов дорог.\n", len(data))
+	// --- 1.1 ПОДКОМАНДЫ (geocash migrate ..., geocash import ..., geocash grid ...) ---
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			requireDB(db)
+			if err := runMigrate(db, os.Args[2:]); err != nil {
+				log.Fatalf("❌ Ошибка миграции: %v", err)
+			}
+			return
+		case "import":
+			if err := runImport(db, cfg, cities, os.Args[2:]); err != nil {
+				log.Fatalf("❌ Ошибка импорта: %v", err)
+			}
+			return
+		case "grid":
+			requireDB(db)
+			if err := runGrid(db, cfg, cities, os.Args[2:]); err != nil {
+				log.Fatalf("❌ Ошибка генерации зон: %v", err)
+			}
+			return
+		default:
+			log.Fatalf("❌ Неизвестная команда %q (доступно: migrate, import, grid)", os.Args[1])
+		}
+	}
 
-			// 2. Интегрируем в базу (обновляем зоны)
-			integrator := postgres.NewTrafficIntegrator(db)
+	// --- 1.2 АВТОМИГРАЦИЯ ПРИ СТАРТЕ ---
+	if cfg.DB.AutoMigrate && db != nil {
+		if err := autoMigrate(db); err != nil {
+			log.Fatalf("❌ Ошибка автомиграции: %v", err)
+		}
+	}
 
-			// Используем таймаут для безопасности
-			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-			defer cancel()
+	// --- 2. ИМПОРТ CSV (ТРАФИК) ---
+	// Уже загруженный файл (по хэшу содержимого в истории импортов) пропускается
+	trafficStore := newTrafficBackend(db, cfg, cities)
+	pipeline := trafficStore.pipeline(cfg, cities)
+	if _, err := os.Stat(cfg.Traffic.CSVPath); err == nil {
+		fmt.Println("📂 Найден CSV файл, начинаем интеграцию...")
+		importTraffic(pipeline, cfg, cfg.Traffic.CSVPath, cfg.Traffic.ForceReimport)
+	}
 
-			err = integrator.EnrichZonesWithTraffic(ctx, data)
-			if err != nil {
-				log.Printf("❌ Ошибка интеграции в БД: %v", err)
-			} else {
-				fmt.Println("🚀 Успех! Зоны обновлены данными о трафике.")
-				// Переименуем файл, чтобы не грузить его при каждом рестарте
-				os.Rename(csvPath, csvPath+".processed")
-			}
-		}
+	// --- 2.1 ИМПОРТ ОПЕРАЦИОННЫХ ДАННЫХ (транзакции, загрузки, обслуживание) ---
+	if db != nil {
+		importOperations(db, cfg.Operations)
 	}
 
 	// --- 3. ИНИЦИАЛИЗАЦИЯ СЕРВИСОВ ---
 
-	// ВАЖНО: Сейчас здесь стоит Mock (фейковые данные).
-	// Если у вас готов postgres.NewTerminalRepository(db), замените строку ниже на него.
-	// Например: repo := postgres.NewTerminalRepository(db)
-	repo := terminal.NewMockRepository()
+	// Справочник банков: бренды из источников приводятся к id банка
+	brands := brand.NewDictionary(cfg.Brands)
 
-	gridSvc := analytics.NewGridService()
-	osmProv := provider.NewOSMProvider()
+	// Снимки источников и изменения сети хранятся в Postgres
+	var snapshots terminal.SnapshotStore
+	if db != nil && cfg.ATMSources.Snapshots {
+		snapshots = postgres.NewSnapshotRepository(db, cfg.ATMSources.MoveThresholdM, cfg.ATMSources.SnapshotRetention)
+	}
+
+	// Праздники для правил PH в режиме работы банкоматов
+	holidays, err := openinghours.NewHolidays(cfg.Holidays.Fixed, cfg.Holidays.Dates)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
 
 	// Инициализация Dashboard Service (Бизнес логика)
-	dashSvc := dashboard.NewService(repo, osmProv, gridSvc)
+	dashSvc := newDashboardService(db, cfg, cities, brands, trafficStore, snapshots, holidays)
+	dashSvc.Restore(context.Background())                        // данные прошлого запуска - до ответа источников
+	go dashSvc.Run(context.Background(), cfg.ATMSources.Refresh) // первое обновление - сразу при старте
 
 	// Инициализация Handler (HTTP слой)
 	dashHandler := dashboard.NewHandler(dashSvc)
 
+	// Импорт трафика из браузера: файл сохраняется, загрузка идет в фоне
+	jobs := ingest.NewJobManager(pipeline, cfg.Traffic.Timeout)
+	jobsHandler := ingest.NewJobsHandler(jobs, cfg.Traffic)
+
 	// --- 4. РОУТИНГ И СТАРТ ---
-	http.HandleFunc("/api/dashboard", func(w http.ResponseWriter, r *http.Request) {
-		// CORS заголовки для фронтенда
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		if r.Method == "OPTIONS" {
-			return
-		}
-		dashHandler.ServeHTTP(w, r)
-	})
+	router := api.NewRouter(cfg.Server.CORSOrigin)
+	router.Handle("GET /api/dashboard", dashHandler) // старый адрес, его использует фронтенд
+	router.Handle("GET /api/v1/dashboard", dashHandler)
+	router.Handle("GET /api/v1/cities", dashboard.NewCitiesHandler(cities))
+	router.Handle("GET /api/v1/banks/unmatched", dashboard.NewUnmatchedBanksHandler(brands))
+	router.Handle("GET /api/v1/expansion", analytics.NewExpansionHandler(trafficStore.zones, cities, dashSvc))
+	router.Handle("GET /api/v1/imports", ingest.NewRunsHandler(trafficStore.runs))
+	router.Handle("POST /api/v1/imports/traffic", http.HandlerFunc(jobsHandler.Upload))
+	router.Handle("GET /api/v1/imports/jobs", http.HandlerFunc(jobsHandler.List))
+	router.Handle("GET /api/v1/imports/jobs/{id}", http.HandlerFunc(jobsHandler.Get))
+	router.Handle("DELETE /api/v1/imports/jobs/{id}", http.HandlerFunc(jobsHandler.Cancel))
 
-	fmt.Println("🚀 GeoSmart Backend running on http://localhost:8080")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
+	if snapshots != nil {
+		router.Handle("GET /api/v1/network/changes", dashboard.NewNetworkHandler(snapshots, cities))
+	}
+
+	// Эффективность терминалов считается по операционным данным из Postgres
+	if db != nil {
+		effSvc := analytics.NewEfficiencyService(postgres.NewAnalyticsRepository(db))
+		router.Handle("GET /api/v1/efficiency", analytics.NewEfficiencyHandler(effSvc))
+	}
+
+	srv := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+	fmt.Printf("🚀 GeoSmart Backend running on http://localhost:%d\n", cfg.Server.Port)
+	if err := srv.ListenAndServe(); err != nil {
 		fmt.Println("Error starting server:", err)
 	}
 }
 
-// Вспомогательная функция для чтения ENV
-func getEnv(key, fallback string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+// newDashboardService собирает сервис карты: репозиторий терминалов, источники
+// банкоматов и тепловую карту из хранилища трафика
+func newDashboardService(db *sql.DB, cfg config.Config, cities *city.Registry, brands *brand.Dictionary,
+	trafficStore trafficBackend, snapshots terminal.SnapshotStore, holidays openinghours.Calendar) *dashboard.Service {
+	// Mock (фейковые данные) используется только в демо-режиме,
+	// в остальных случаях данные по терминалам берутся из Postgres
+	var repo terminal.Repository
+	if cfg.DemoMode {
+		fmt.Println("🎭 Демо-режим: данные терминалов генерируются случайно")
+		repo = terminal.NewMockRepository()
+	} else {
+		repo = postgres.NewTerminalRepository(db, cfg.Bank)
+	}
+
+	// Тепловая карта по зонам трафика (в демо-режиме с Postgres - синтетическая сетка)
+	var zones traffic.ZoneRepository
+	if !cfg.DemoMode || cfg.Traffic.Backend == config.TrafficBackendMemory {
+		zones = trafficStore.zones
+	}
+
+	return dashboard.NewService(repo, newATMSources(db, cfg, brands), analytics.NewGridService(cfg.Grid), zones, cities,
+		cfg.Bank, cfg.ATMSources.MergeRadiusM, snapshots, holidays, newDatasetStore(db, cfg.ATMSources),
+		trafficStore.terminals, cfg.DemoMode)
+}
+
+// openDB открывает пул соединений с Postgres и проверяет связь
+func openDB(cfg config.DBConfig) (*sql.DB, error) {
+	fmt.Println("🔌 Подключение к БД...", cfg.SafeDSN())
+	db, err := sql.Open("postgres", cfg.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия соединения с БД: %w", err)
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	// Проверяем пинг
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// requireDB - команда без Postgres не имеет смысла
+func requireDB(db *sql.DB) {
+	if db == nil {
+		log.Fatalf("❌ Команде нужна БД, а она недоступна")
+	}
+}
+
+// importOperations загружает выгрузки процессинга из dir, если они там есть.
+// Повторный импорт безопасен: уже загруженные строки пропускаются.
+func importOperations(db *sql.DB, cfg config.OperationsConfig) {
+	importer := postgres.NewOperationsImporter(db)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	imports := []struct {
+		file string
+		run  func(path string) (int64, error)
+	}{
+		{"transactions.csv", func(path string) (int64, error) {
+			items, err := loader.LoadTransactionsCSV(path)
+			if err != nil {
+				return 0, err
+			}
+			return importer.SaveTransactions(ctx, items)
+		}},
+		{"loadings.csv", func(path string) (int64, error) {
+			items, err := loader.LoadLoadingsCSV(path)
+			if err != nil {
+				return 0, err
+			}
+			return importer.SaveLoadings(ctx, items)
+		}},
+		{"service_history.csv", func(path string) (int64, error) {
+			items, err := loader.LoadServiceHistoryCSV(path)
+			if err != nil {
+				return 0, err
+			}
+			return importer.SaveServiceHistory(ctx, items)
+		}},
+	}
+
+	for _, imp := range imports {
+		path := filepath.Join(cfg.Dir, imp.file)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		count, err := imp.run(path)
+		if err != nil {
+			log.Printf("❌ Ошибка импорта %s: %v", path, err)
+			continue
+		}
+		fmt.Printf("📥 %s: загружено новых строк %d\n", path, count)
 	}
-	return fallback
 }
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"geocash/internal/analytics"
+	"geocash/internal/config"
+	"geocash/internal/domain/brand"
+	"geocash/internal/domain/city"
+	"geocash/internal/domain/terminal"
+	"geocash/internal/domain/traffic"
+	"geocash/internal/openinghours"
+)
+
+// С traffic.backend: memory зоны с нашим банкоматом не должны попадать
+// в кандидаты на расширение: банкоматы передаются в хранилище зон после обновления
+func TestMemoryBackendExpansionSkipsOwnATMs(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.DemoMode = true
+	cfg.Traffic.Backend = config.TrafficBackendMemory
+	cfg.ATMSources.Store = config.DatasetStoreOff
+	cfg.ATMSources.Snapshots = false
+	cities, err := city.NewRegistry(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	astana := cities.Default()
+
+	// Две ячейки сетки: в первой наш банкомат из обхода и больше трафика
+	cells := analytics.NewGridService(cfg.Grid).Cells(astana)
+	own, free := cells[len(cells)/2], cells[len(cells)/2+10]
+
+	survey := filepath.Join(dir, "survey.csv")
+	writeFile(t, survey, fmt.Sprintf("bank,name,lat,lng\nForte,Forte ATM,%f,%f\n", own.Lat, own.Lng))
+	cfg.ATMSources.Sources = []config.ATMSourceConfig{{Type: config.ATMSourceSurvey, Role: config.ATMRoleAll, Path: survey}}
+
+	csvPath := filepath.Join(dir, "traffic.csv")
+	d := cfg.Grid.CellRadius / 4
+	writeFile(t, csvPath, fmt.Sprintf("edge_id,weekday_traffic,geometry\n"+
+		"1,1000,\"LINESTRING(%f %f,%f %f)\"\n2,100,\"LINESTRING(%f %f,%f %f)\"\n",
+		own.Lng-d, own.Lat, own.Lng+d, own.Lat, free.Lng-d, free.Lat, free.Lng+d, free.Lat))
+
+	backend := newTrafficBackend(nil, cfg, cities)
+	if _, err := importTraffic(backend.pipeline(cfg, cities), cfg, csvPath, false); err != nil {
+		t.Fatalf("importTraffic: %v", err)
+	}
+
+	holidays, err := openinghours.NewHolidays(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := newDashboardService(nil, cfg, cities, brand.NewDictionary(cfg.Brands), backend, nil, holidays)
+	// Отмененный контекст: Run делает одно обновление и выходит
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	svc.Run(ctx, cfg.ATMSources.Refresh)
+
+	rec := httptest.NewRecorder()
+	analytics.NewExpansionHandler(backend.zones, cities, svc).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/expansion?city=astana&limit=500", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var resp analytics.ExpansionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Code != free.Code {
+		t.Fatalf("first candidate = %+v, want %s", resp.Candidates[:min(len(resp.Candidates), 1)], free.Code)
+	}
+	for _, z := range resp.Candidates {
+		if z.Code == own.Code {
+			t.Errorf("зона %s с нашим банкоматом в кандидатах", own.Code)
+		}
+	}
+}
+
+// Два файла обхода с одинаковым именем в разных каталогах - два разных источника:
+// читаются оба, ID банкоматов не совпадают
+func TestSurveySourcesWithSameFileName(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Default()
+	cfg.DemoMode = true
+	cfg.Traffic.Backend = config.TrafficBackendMemory
+	cfg.ATMSources.Store = config.DatasetStoreOff
+	cfg.ATMSources.Snapshots = false
+	cfg.ATMSources.Sources = nil
+	for i, line := range []string{"Halyk,Halyk ATM,51.13,71.43", "Kaspi,Kaspi ATM,51.14,71.44"} {
+		path := filepath.Join(dir, fmt.Sprint(i), "survey.csv")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, path, "bank,name,lat,lng\n"+line+"\n")
+		cfg.ATMSources.Sources = append(cfg.ATMSources.Sources,
+			config.ATMSourceConfig{Type: config.ATMSourceSurvey, Role: config.ATMRoleAll, Path: path})
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	cities, err := city.NewRegistry(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	holidays, err := openinghours.NewHolidays(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := newDashboardService(nil, cfg, cities, brand.NewDictionary(cfg.Brands), newTrafficBackend(nil, cfg, cities), nil, holidays)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	svc.Run(ctx, cfg.ATMSources.Refresh)
+
+	resp := svc.GetDashboardData(context.Background(), cities.Default(), traffic.AllDay, terminal.Filter{}, time.Time{})
+	ids := make(map[string]string)
+	for _, atm := range resp.Competitors {
+		ids[atm.ID] = atm.BankID
+	}
+	if len(ids) != 2 {
+		t.Fatalf("competitors = %v, want 2 ATMs with distinct IDs", ids)
+	}
+	banks := make(map[string]bool)
+	for _, bank := range ids {
+		banks[bank] = true
+	}
+	if !banks["halyk"] || !banks["kaspi"] {
+		t.Errorf("banks = %v, want halyk and kaspi (прочитаны оба файла)", banks)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"geocash/internal/platform/postgres"
+	"geocash/migrations"
+)
+
+const migrateUsage = "использование: geocash migrate up | down [N] | status"
+
+// runMigrate - подкоманда `geocash migrate up|down|status`
+func runMigrate(db *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := postgres.NewMigrator(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Применено миграций: %d\n", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("некорректное количество шагов %q: %s", args[1], migrateUsage)
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("↩️ Откачено миграций: %d\n", reverted)
+
+	case "status":
+		current, list, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Текущая версия схемы: %d\n", current)
+		for _, m := range list {
+			mark := "  "
+			if m.Applied {
+				mark = "✅"
+			}
+			fmt.Printf("%s %06d_%s\n", mark, m.Version, m.Name)
+		}
+
+	default:
+		return fmt.Errorf("неизвестная команда %q: %s", args[0], migrateUsage)
+	}
+	return nil
+}
+
+// autoMigrate применяет новые миграции при старте сервера.
+// Advisory lock внутри мигратора не дает двум репликам мигрировать одновременно.
+func autoMigrate(db *sql.DB) error {
+	migrator, err := postgres.NewMigrator(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🗄️ Автомиграция: применено миграций %d\n", applied)
+	return nil
+}
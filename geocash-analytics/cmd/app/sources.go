@@ -0,0 +1,53 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	"geocash/internal/config"
+	"geocash/internal/dashboard"
+	"geocash/internal/domain/brand"
+	"geocash/internal/domain/terminal"
+	"geocash/internal/platform/filestore"
+	"geocash/internal/platform/postgres"
+	"geocash/internal/platform/provider"
+)
+
+// newATMSources собирает источники банкоматов из atm_sources в порядке приоритета.
+// Справочнику нужна БД: без нее (демо) он пропускается.
+func newATMSources(db *sql.DB, cfg config.Config, brands *brand.Dictionary) []dashboard.Source {
+	var sources []dashboard.Source
+	for _, sc := range cfg.ATMSources.Sources {
+		var src terminal.Source
+		switch sc.Type {
+		case config.ATMSourceOSM:
+			src = provider.New(cfg.OSM, brands)
+		case config.ATMSourceRegistry:
+			if db == nil {
+				fmt.Println("⚠️ Источник registry пропущен: БД недоступна")
+				continue
+			}
+			src = postgres.NewRegistrySource(db, cfg.Bank)
+		case config.ATMSourceSurvey:
+			src = provider.NewSurveySource(sc.Path, brands)
+		}
+		sources = append(sources, dashboard.Source{Source: src, Role: sc.Role, Key: sc.Key()})
+	}
+	return sources
+}
+
+// newDatasetStore - хранилище последнего удачного набора банкоматов (atm_sources.store);
+// nil - не хранить. Для postgres нужна БД: без нее набор не сохраняется.
+func newDatasetStore(db *sql.DB, cfg config.ATMSourcesConfig) terminal.DatasetStore {
+	switch cfg.Store {
+	case config.DatasetStoreFile:
+		return filestore.NewDatasetStore(cfg.StorePath)
+	case config.DatasetStorePostgres:
+		if db == nil {
+			fmt.Println("⚠️ Банкоматы не сохраняются между запусками: БД недоступна")
+			return nil
+		}
+		return postgres.NewDatasetRepository(db)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"geocash/internal/domain/terminal"
+)
+
+// Пороги оценки эффективности
+const (
+	minTxPerDay       = 20.0  // меньше - терминал почти не используется
+	effectiveTxPerDay = 100.0 // больше - терминал востребован
+	idleLoadPercent   = 80.0  // средняя загрузка выше - наличность простаивает
+	lowLoadPercent    = 20.0  // средняя загрузка ниже - риск опустошения
+	encashmentPercent = 10.0  // текущий остаток ниже - нужна инкассация
+)
+
+// EfficiencyService рассчитывает эффективность терминала за период
+type EfficiencyService struct {
+	repo Repository
+}
+
+func NewEfficiencyService(repo Repository) *EfficiencyService {
+	return &EfficiencyService{repo: repo}
+}
+
+// Evaluate собирает метрики и остаток, вычисляет статус и рекомендацию,
+// сохраняет отчет в efficiency_reports. Неизвестный терминал - terminal.ErrNotFound.
+func (s *EfficiencyService) Evaluate(ctx context.Context, terminalID string, start, end time.Time) (EfficiencyReport, error) {
+	exists, err := s.repo.TerminalExists(ctx, terminalID)
+	if err != nil {
+		return EfficiencyReport{}, err
+	}
+	if !exists {
+		return EfficiencyReport{}, fmt.Errorf("терминал %s: %w", terminalID, terminal.ErrNotFound)
+	}
+
+	metrics, err := s.repo.GetPerformanceMetricsByPeriod(ctx, terminalID, start, end)
+	if err != nil {
+		return EfficiencyReport{}, err
+	}
+
+	report := EfficiencyReport{
+		TerminalID:  terminalID,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Metrics:     metrics,
+	}
+
+	balance, err := s.repo.GetLastKnownBalance(ctx, terminalID)
+	switch {
+	case err == nil:
+		report.Balance = &balance
+	case !errors.Is(err, terminal.ErrNotFound):
+		return EfficiencyReport{}, err
+	}
+
+	report.Status, report.Recommendation = evaluate(metrics, report.Balance, end.Sub(start))
+
+	if err := s.repo.SaveEfficiencyReport(ctx, &report); err != nil {
+		return EfficiencyReport{}, err
+	}
+	return report, nil
+}
+
+// evaluate - правила оценки: статус и рекомендации по метрикам периода
+func evaluate(m PerformanceMetrics, balance *terminal.CashBalance, period time.Duration) (string, string) {
+	days := period.Hours() / 24
+	if days < 1 {
+		days = 1
+	}
+	txPerDay := float64(m.TotalTransactions) / days
+
+	var advice []string
+	status := StatusNormal
+
+	switch {
+	case m.TotalTransactions == 0:
+		status = StatusIneffective
+		advice = append(advice, "Терминал не использовался в периоде: проверьте работоспособность или рассмотрите перенос")
+	case txPerDay < minTxPerDay:
+		status = StatusIneffective
+		advice = append(advice, fmt.Sprintf("Низкая востребованность (%.1f операций в день): рассмотрите перенос в зону с большим трафиком", txPerDay))
+	}
+
+	if m.LastServiceCriticality {
+		status = StatusIneffective
+		advice = append(advice, "В периоде были критические ремонты: запланируйте диагностику или замену оборудования")
+	}
+
+	switch {
+	case m.AverageLoadingPercent > idleLoadPercent && txPerDay < effectiveTxPerDay:
+		advice = append(advice, fmt.Sprintf("Наличность простаивает (средняя загрузка %.0f%%): уменьшите сумму подкрепления", m.AverageLoadingPercent))
+	case m.AverageLoadingPercent > 0 && m.AverageLoadingPercent < lowLoadPercent:
+		advice = append(advice, fmt.Sprintf("Риск опустошения (средняя загрузка %.0f%%): увеличьте сумму или частоту инкассации", m.AverageLoadingPercent))
+	}
+
+	if balance != nil && balance.MaxCapacity > 0 && balance.CurrentBalance/balance.MaxCapacity*100 < encashmentPercent {
+		advice = append(advice, "Текущий остаток ниже 10% емкости: требуется инкассация")
+	}
+
+	if status == StatusNormal && txPerDay >= effectiveTxPerDay &&
+		m.AverageLoadingPercent >= lowLoadPercent && m.AverageLoadingPercent <= idleLoadPercent {
+		status = StatusEffective
+	}
+
+	if len(advice) == 0 {
+		if status == StatusEffective {
+			advice = append(advice, "Терминал работает эффективно: изменений не требуется")
+		} else {
+			advice = append(advice, "Показатели в норме: продолжайте мониторинг")
+		}
+	}
+	return status, strings.Join(advice, "; ")
+}
@@ -0,0 +1,91 @@
+package analytics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"geocash/internal/api"
+	"geocash/internal/domain/city"
+	"geocash/internal/domain/traffic"
+	"geocash/internal/openinghours"
+)
+
+// Ограничения на количество зон-кандидатов
+const (
+	defaultExpansionLimit = 20
+	maxExpansionLimit     = 500
+)
+
+// ExpansionResponse - зоны без наших терминалов, самые оживленные в срезе первыми
+type ExpansionResponse struct {
+	City       city.City             `json:"city"`
+	TimeSlice  traffic.TimeSlice     `json:"timeSlice"`
+	Candidates []traffic.ZoneTraffic `json:"candidates"`
+	// At - при запросе с at: зону покрывают только терминалы, работающие в этот момент
+	At              *time.Time `json:"at,omitempty"`
+	ClosedTerminals int        `json:"closedTerminals,omitempty"`
+}
+
+// TerminalHours - режим работы наших терминалов (реализует dashboard.Service)
+type TerminalHours interface {
+	// ClosedTerminals - terminal_id терминалов города, закрытых в момент at
+	ClosedTerminals(c city.City, at time.Time) []string
+}
+
+// ExpansionHandler - GET /api/v1/expansion?city=almaty&day=weekend&hours=18-22&limit=20&at=2026-10-18T23:00
+type ExpansionHandler struct {
+	zones  traffic.ZoneRepository
+	cities *city.Registry
+	hours  TerminalHours
+}
+
+func NewExpansionHandler(zones traffic.ZoneRepository, cities *city.Registry, hours TerminalHours) *ExpansionHandler {
+	return &ExpansionHandler{zones: zones, cities: cities, hours: hours}
+}
+
+func (h *ExpansionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	c, err := h.cities.Resolve(q.Get("city"))
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	slice, err := traffic.ParseTimeSlice(q.Get("day"), q.Get("hours"))
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	limit := defaultExpansionLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxExpansionLimit {
+			api.WriteError(w, http.StatusBadRequest, errors.New("параметр limit: ожидается число от 1 до 500"))
+			return
+		}
+		limit = n
+	}
+
+	resp := ExpansionResponse{City: c, TimeSlice: slice}
+	var closed []string
+	if v := q.Get("at"); v != "" {
+		at, err := openinghours.ParseAt(v, c.Location)
+		if err != nil {
+			api.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		closed = h.hours.ClosedTerminals(c, at)
+		resp.At, resp.ClosedTerminals = &at, len(closed)
+	}
+
+	resp.Candidates, err = h.zones.ExpansionCandidates(r.Context(), c.Bounds(), slice, closed, limit)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,70 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"geocash/internal/api"
+	"geocash/internal/domain/terminal"
+)
+
+// Период по умолчанию, если from/to не заданы
+const defaultPeriodDays = 30
+
+// EfficiencyHandler - GET /api/v1/efficiency?id=AST-001&from=2026-01-01&to=2026-01-31
+type EfficiencyHandler struct {
+	service *EfficiencyService
+}
+
+func NewEfficiencyHandler(service *EfficiencyService) *EfficiencyHandler {
+	return &EfficiencyHandler{service: service}
+}
+
+func (h *EfficiencyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	id := q.Get("id")
+	if id == "" {
+		api.WriteError(w, http.StatusBadRequest, errors.New("параметр id обязателен"))
+		return
+	}
+
+	// to включительно: до конца указанного дня
+	now := time.Now()
+	end := now
+	if v := q.Get("to"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			api.WriteError(w, http.StatusBadRequest, fmt.Errorf("параметр to: ожидается дата YYYY-MM-DD"))
+			return
+		}
+		end = d.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	start := end.AddDate(0, 0, -defaultPeriodDays)
+	if v := q.Get("from"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			api.WriteError(w, http.StatusBadRequest, fmt.Errorf("параметр from: ожидается дата YYYY-MM-DD"))
+			return
+		}
+		start = d
+	}
+	if !start.Before(end) {
+		api.WriteError(w, http.StatusBadRequest, errors.New("from должен быть раньше to"))
+		return
+	}
+
+	report, err := h.service.Evaluate(r.Context(), id, start, end)
+	if err != nil {
+		if errors.Is(err, terminal.ErrNotFound) {
+			api.WriteError(w, http.StatusNotFound, err)
+			return
+		}
+		api.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, report)
+}
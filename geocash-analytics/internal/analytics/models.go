@@ -1,9 +1,35 @@
 package analytics
 
+import (
+	"time"
+
+	"geocash/internal/domain/terminal"
+)
+
 // PerformanceMetrics - структура для сбора статистики по терминалу
 type PerformanceMetrics struct {
-	TotalTransactions      int     // Количество транзакций
-	TotalThroughputAmount  int     // Общая сумма
-	AverageLoadingPercent  float64 // Средняя загрузка в %
-	LastServiceCriticality bool    // Были ли критические ремонты
+	TotalTransactions      int     `json:"totalTransactions"`      // Количество транзакций
+	TotalThroughputAmount  float64 `json:"totalThroughputAmount"`  // Общая сумма
+	AverageLoadingPercent  float64 `json:"averageLoadingPercent"`  // Средняя загрузка в %
+	LastServiceCriticality bool    `json:"lastServiceCriticality"` // Были ли критические ремонты
+}
+
+// Статусы эффективности в efficiency_reports
+const (
+	StatusEffective   = "EFFECTIVE"
+	StatusNormal      = "NORMAL"
+	StatusIneffective = "INEFFECTIVE"
+)
+
+// EfficiencyReport - итоговый отчет по терминалу за период
+type EfficiencyReport struct {
+	ID             int64                 `json:"id"`
+	TerminalID     string                `json:"terminalId"`
+	PeriodStart    time.Time             `json:"periodStart"`
+	PeriodEnd      time.Time             `json:"periodEnd"`
+	Metrics        PerformanceMetrics    `json:"metrics"`
+	Balance        *terminal.CashBalance `json:"balance"` // nil, если замеров остатка нет
+	Status         string                `json:"efficiencyStatus"`
+	Recommendation string                `json:"recommendation"`
+	CalculatedAt   time.Time             `json:"calculatedAt"`
 }
@@ -1,3 +1,25 @@
 // internal/analytics/repository.go
 
 package analytics
+
+import (
+	"context"
+	"time"
+
+	"geocash/internal/domain/terminal"
+)
+
+// Repository - операционные данные терминалов (реализация: postgres.AnalyticsRepository)
+type Repository interface {
+	// TerminalExists проверяет, есть ли терминал в справочнике
+	TerminalExists(ctx context.Context, terminalID string) (bool, error)
+
+	// GetPerformanceMetricsByPeriod собирает метрики терминала за период
+	GetPerformanceMetricsByPeriod(ctx context.Context, terminalID string, start, end time.Time) (PerformanceMetrics, error)
+
+	// GetLastKnownBalance - последний замер остатка (terminal.ErrNotFound, если замеров нет)
+	GetLastKnownBalance(ctx context.Context, terminalID string) (terminal.CashBalance, error)
+
+	// SaveEfficiencyReport сохраняет отчет в efficiency_reports и заполняет его ID и CalculatedAt
+	SaveEfficiencyReport(ctx context.Context, report *EfficiencyReport) error
+}
@@ -1,6 +1,10 @@
 package analytics
 
 import (
+	"fmt"
+	"geocash/internal/config"
+	"geocash/internal/domain/city"
+	"geocash/internal/domain/traffic"
 	"math"
 )
 
@@ -19,63 +23,137 @@ type GeoJSONGeometry struct {
 	Coordinates [][][]float64 `json:"coordinates"`
 }
 
-type GridService struct{}
+type GridService struct {
+	cfg config.GridConfig
+}
 
-func NewGridService() *GridService {
-	return &GridService{}
+func NewGridService(cfg config.GridConfig) *GridService {
+	return &GridService{cfg: cfg}
 }
 
-// GenerateHexGrid создает сетку на весь город
-func (s *GridService) GenerateHexGrid() GeoJSONFeatureCollection {
-	// Координаты Астаны
-	minLat, maxLat := 51.00, 51.30
-	minLng, maxLng := 71.30, 71.65
-	radius := 0.002
+// HexCell - ячейка гексагональной сетки. Code стабилен, пока не меняются
+// bbox города и cell_radius, поэтому по нему сопоставляются зоны в БД и ячейки карты.
+type HexCell struct {
+	Code    string
+	Row     int
+	Col     int
+	Lat     float64 // центр
+	Lng     float64
+	Polygon [][]float64 // замкнутое кольцо [lng, lat]
+}
 
-	var features []GeoJSONFeature
-	h := radius * math.Sin(math.Pi/3)
+// HexCode - код ячейки сетки города по ряду и колонке
+func HexCode(cityID string, row, col int) string {
+	return fmt.Sprintf("%s-hex-r%03dc%03d", cityID, row, col)
+}
+
+// Cells покрывает bbox города гексагонами без наложений. Нечетные ряды сдвинуты
+// на полшага; шаг по долготе растянут на aspect так же, как сами гексагоны.
+func (s *GridService) Cells(c city.City) []HexCell {
+	minLat, maxLat := c.BBox.MinLat, c.BBox.MaxLat
+	minLng, maxLng := c.BBox.MinLng, c.BBox.MaxLng
+	radius := s.cfg.CellRadius
+	aspect := s.aspect(c)
+
+	var cells []HexCell
+	h := radius * math.Sin(math.Pi/3) * aspect
 	rowHeight := 1.5 * radius
 	colWidth := 2 * h
-	isOddRow := false
 
-	for lat := minLat; lat < maxLat; lat += rowHeight {
-		currLng := minLng
-		if isOddRow {
-			currLng += h
+	for row := 0; minLat+float64(row)*rowHeight < maxLat; row++ {
+		lat := minLat + float64(row)*rowHeight
+		startLng := minLng
+		if row%2 == 1 {
+			startLng += h
+		}
+		for col := 0; startLng+float64(col)*colWidth < maxLng; col++ {
+			lng := startLng + float64(col)*colWidth
+			cells = append(cells, HexCell{
+				Code:    HexCode(c.ID, row, col),
+				Row:     row,
+				Col:     col,
+				Lat:     lat,
+				Lng:     lng,
+				Polygon: createHexagon(lat, lng, radius, aspect),
+			})
+		}
+	}
+	return cells
+}
+
+// aspect - растяжение по долготе: из конфига или по широте центра города
+func (s *GridService) aspect(c city.City) float64 {
+	if s.cfg.Aspect > 0 {
+		return s.cfg.Aspect
+	}
+	return 1 / math.Cos(c.Center.Lat*math.Pi/180)
+}
+
+// GenerateHexGrid создает синтетическую сетку на весь город (пока нет данных трафика)
+func (s *GridService) GenerateHexGrid(city city.City) GeoJSONFeatureCollection {
+	var features []GeoJSONFeature
+	for _, c := range s.Cells(city) {
+		weight := calculateWeight(c.Lat-city.Center.Lat, c.Lng-city.Center.Lng)
+		if weight > s.cfg.MinWeight {
+			features = append(features, GeoJSONFeature{
+				Type:       "Feature",
+				Properties: map[string]interface{}{"weight": weight, "zoneCode": c.Code},
+				Geometry:   GeoJSONGeometry{Type: "Polygon", Coordinates: [][][]float64{c.Polygon}},
+			})
+		}
+	}
+	return GeoJSONFeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+// ZoneHeatmap строит тепловую карту по реальным зонам трафика:
+// вес зоны - ее трафик в срезе относительно самой оживленной зоны
+func (s *GridService) ZoneHeatmap(zones []traffic.ZoneTraffic) GeoJSONFeatureCollection {
+	maxTraffic := 0.0
+	for _, z := range zones {
+		maxTraffic = math.Max(maxTraffic, z.Traffic)
+	}
+
+	features := []GeoJSONFeature{}
+	for _, z := range zones {
+		weight := 0.0
+		if maxTraffic > 0 {
+			weight = z.Traffic / maxTraffic
 		}
-		for lng := currLng; lng < maxLng; lng += colWidth {
-			weight := s.calculateWeight(lat, lng)
-			if weight > 0.05 {
-				poly := s.createHexagon(lat, lng, radius)
-				features = append(features, GeoJSONFeature{
-					Type:       "Feature",
-					Properties: map[string]interface{}{"weight": weight},
-					Geometry:   GeoJSONGeometry{Type: "Polygon", Coordinates: [][][]float64{poly}},
-				})
-			}
+		if weight <= s.cfg.MinWeight {
+			continue
 		}
-		isOddRow = !isOddRow
+		features = append(features, GeoJSONFeature{
+			Type: "Feature",
+			Properties: map[string]interface{}{
+				"weight":   weight,
+				"traffic":  math.Round(z.Traffic),
+				"zone":     z.Name,
+				"zoneCode": z.Code,
+			},
+			Geometry: GeoJSONGeometry{Type: "Polygon", Coordinates: [][][]float64{z.Polygon}},
+		})
 	}
 	return GeoJSONFeatureCollection{Type: "FeatureCollection", Features: features}
 }
 
-func (s *GridService) calculateWeight(lat, lng float64) float64 {
-	centerDist := math.Sqrt(math.Pow(lat-51.13, 2) + math.Pow(lng-71.43, 2))
+// calculateWeight - синтетический вес по смещению от центра города (в градусах)
+func calculateWeight(dLat, dLng float64) float64 {
+	centerDist := math.Sqrt(math.Pow(dLat, 2) + math.Pow(dLng, 2))
 	if centerDist > 0.16 {
 		return 0
 	}
 
 	weight := 0.0
 	// Шум
-	noise := math.Sin(lat*400) * math.Cos(lng*400)
+	noise := math.Sin(dLat*400) * math.Cos(dLng*400)
 	weight += (noise + 1) * 0.15
 
-	// Хотспоты
+	// Хотспоты (смещения от центра)
 	hotspots := []struct{ lat, lng, p float64 }{
-		{51.128, 71.430, 0.8}, {51.165, 71.425, 0.7}, {51.131, 71.402, 0.9},
+		{-0.002, 0.000, 0.8}, {0.035, -0.005, 0.7}, {0.001, -0.028, 0.9},
 	}
 	for _, p := range hotspots {
-		d := math.Sqrt(math.Pow(lat-p.lat, 2) + math.Pow(lng-p.lng, 2))
+		d := math.Sqrt(math.Pow(dLat-p.lat, 2) + math.Pow(dLng-p.lng, 2))
 		if d < 0.035 {
 			weight += p.p * (1.0 - d/0.035)
 		}
@@ -86,9 +164,8 @@ func (s *GridService) calculateWeight(lat, lng float64) float64 {
 	return weight
 }
 
-func (s *GridService) createHexagon(lat, lng, r float64) [][]float64 {
+func createHexagon(lat, lng, r, aspect float64) [][]float64 {
 	var coords [][]float64
-	aspect := 1.65
 	for i := 0; i <= 6; i++ {
 		angle := math.Pi / 180 * (60.0*float64(i) - 30.0)
 		coords = append(coords, []float64{lng + r*math.Cos(angle)*aspect, lat + r*math.Sin(angle)})
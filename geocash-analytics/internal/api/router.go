@@ -0,0 +1,50 @@
+// Package api - общий HTTP-слой: роутер с CORS и JSON-ответы
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Router - маршруты /api/... с CORS-заголовками для фронтенда
+type Router struct {
+	mux        *http.ServeMux
+	corsOrigin string
+}
+
+func NewRouter(corsOrigin string) *Router {
+	return &Router{mux: http.NewServeMux(), corsOrigin: corsOrigin}
+}
+
+// Handle регистрирует обработчик (шаблоны http.ServeMux: "GET /api/v1/efficiency")
+func (rt *Router) Handle(pattern string, h http.Handler) {
+	rt.mux.Handle(pattern, h)
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", rt.corsOrigin)
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	rt.mux.ServeHTTP(w, r)
+}
+
+// ErrorResponse - тело ответа при ошибке
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// WriteJSON отдает v как JSON с заданным статусом
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// WriteError отдает ошибку в формате {"error": "..."}
+func WriteError(w http.ResponseWriter, status int, err error) {
+	WriteJSON(w, status, ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,497 @@
+// Package config загружает настройки сервиса из config/config.yaml,
+// применяет переопределения из переменных окружения и проверяет их
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath - путь к конфигу, если не задан CONFIG_PATH
+const DefaultPath = "config/config.yaml"
+
+// Config - все настройки сервиса
+type Config struct {
+	// DemoMode - случайные данные вместо БД (для презентаций без доступа к данным).
+	// Только в этом режиме API отдает сгенерированные (synthetic) данные.
+	DemoMode bool `yaml:"demo_mode"`
+
+	// Cities - города, которые обслуживает сервис; DefaultCity - если город не указан в запросе
+	Cities      []CityConfig `yaml:"cities"`
+	DefaultCity string       `yaml:"default_city"`
+
+	Server     ServerConfig     `yaml:"server"`
+	DB         DBConfig         `yaml:"db"`
+	OSM        OSMConfig        `yaml:"osm"`
+	ATMSources ATMSourcesConfig `yaml:"atm_sources"`
+	Traffic    TrafficConfig    `yaml:"traffic"`
+	Operations OperationsConfig `yaml:"operations"`
+	Grid       GridConfig       `yaml:"grid"`
+	Bank       BankConfig       `yaml:"bank"`
+	Brands     BrandsConfig     `yaml:"brands"`
+	Holidays   HolidaysConfig   `yaml:"holidays"`
+}
+
+// ServerConfig - HTTP сервер
+type ServerConfig struct {
+	Port         int           `yaml:"port"`
+	CORSOrigin   string        `yaml:"cors_origin"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+}
+
+// DBConfig - подключение к Postgres и пул соединений
+type DBConfig struct {
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	Name            string        `yaml:"dbname"`
+	SSLMode         string        `yaml:"sslmode"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	AutoMigrate     bool          `yaml:"auto_migrate"`
+}
+
+// DSN - строка подключения для lib/pq
+func (c DBConfig) DSN() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     fmt.Sprintf("%s:%d", c.Host, c.Port),
+		Path:     c.Name,
+		RawQuery: "sslmode=" + url.QueryEscape(c.SSLMode),
+	}
+	return u.String()
+}
+
+// SafeDSN - строка подключения без пароля (для логов)
+func (c DBConfig) SafeDSN() string {
+	return fmt.Sprintf("postgres://%s@%s:%d/%s?sslmode=%s", c.User, c.Host, c.Port, c.Name, c.SSLMode)
+}
+
+// BBox - прямоугольник в градусах WGS84
+type BBox struct {
+	MinLat float64 `yaml:"min_lat" json:"minLat"`
+	MinLng float64 `yaml:"min_lng" json:"minLng"`
+	MaxLat float64 `yaml:"max_lat" json:"maxLat"`
+	MaxLng float64 `yaml:"max_lng" json:"maxLng"`
+}
+
+// LatLng - точка в градусах WGS84
+type LatLng struct {
+	Lat float64 `yaml:"lat" json:"lat"`
+	Lng float64 `yaml:"lng" json:"lng"`
+}
+
+// CityConfig - город: границы для OSM, сетки и импорта трафика
+type CityConfig struct {
+	ID       string `yaml:"id"` // латиница, используется в API (?city=almaty) и кодах зон
+	Name     string `yaml:"name"`
+	BBox     BBox   `yaml:"bbox"`
+	Center   LatLng `yaml:"center"`
+	Timezone string `yaml:"timezone"` // IANA, например Asia/Almaty
+}
+
+// Источники данных OSM
+const (
+	OSMSourceOverpass = "overpass"
+	OSMSourceFile     = "file"
+)
+
+// OSMConfig - загрузка банкоматов из OpenStreetMap (Overpass API или локальная выгрузка)
+type OSMConfig struct {
+	// Source - overpass (онлайн) или file (выгрузка .osm / .osm.pbf, без интернета)
+	Source      string `yaml:"source"`
+	OverpassURL string `yaml:"overpass_url"`
+	// OverpassMirrors - зеркала Overpass, опрашиваются по очереди, если основной адрес не отвечает
+	OverpassMirrors []string      `yaml:"overpass_mirrors"`
+	Timeout         time.Duration `yaml:"timeout"`
+	// FilePath - выгрузка OSM для source: file
+	FilePath string `yaml:"file_path"`
+	// DedupRadiusM - отделение (way) и банкомат (node) одного банка ближе этого
+	// расстояния считаются одним терминалом
+	DedupRadiusM float64 `yaml:"dedup_radius_m"`
+}
+
+// Источники банкоматов для карты
+const (
+	ATMSourceOSM      = "osm"      // OpenStreetMap (osm.source: overpass или file)
+	ATMSourceRegistry = "registry" // справочник терминалов банка (таблица terminals)
+	ATMSourceSurvey   = "survey"   // CSV ручного обхода: bank,name,lat,lng
+)
+
+// Какие банкоматы брать из источника
+const (
+	ATMRoleOwn         = "own"         // только наши
+	ATMRoleCompetitors = "competitors" // только конкуренты
+	ATMRoleAll         = "all"         // все (свои узнаются по справочнику brands)
+)
+
+// ATMSourcesConfig - откуда берутся банкоматы на карте
+type ATMSourcesConfig struct {
+	// Sources - по убыванию приоритета: банкомат из источника ниже отбрасывается,
+	// если источник выше уже дал банкомат того же банка в радиусе MergeRadiusM
+	Sources      []ATMSourceConfig `yaml:"sources"`
+	MergeRadiusM float64           `yaml:"merge_radius_m"`
+	// Snapshots - сохранять ответы источника в Postgres (если сеть изменилась) и считать изменения сети
+	Snapshots bool `yaml:"snapshots"`
+	// SnapshotRetention - сколько хранить снимки (последний хранится всегда, история
+	// изменений - тоже); 0 - не удалять
+	SnapshotRetention time.Duration `yaml:"snapshot_retention"`
+	// MoveThresholdM - банкомат сместился больше чем на столько - считаем переносом
+	MoveThresholdM float64 `yaml:"move_threshold_m"`
+	// Refresh - расписание обновления источников
+	Refresh RefreshConfig `yaml:"refresh"`
+	// Store - где хранить последний удачный набор банкоматов (DatasetStore*):
+	// после рестарта карта показывает его, пока источники не ответят
+	Store string `yaml:"store"`
+	// StorePath - каталог для store: file
+	StorePath string `yaml:"store_path"`
+}
+
+// Хранилища последнего удачного набора банкоматов
+const (
+	DatasetStoreFile     = "file"     // JSON-файлы в store_path, по файлу на город
+	DatasetStorePostgres = "postgres" // таблица atm_datasets
+	DatasetStoreOff      = "off"      // не хранить
+)
+
+// RefreshConfig - периодическое обновление банкоматов из источников
+type RefreshConfig struct {
+	// Interval - пауза между успешными обновлениями
+	Interval time.Duration `yaml:"interval"`
+	// RetryMin, RetryMax - после сбоя повтор через RetryMin, дальше пауза удваивается
+	// до RetryMax (со случайным разбросом, чтобы не бить в Overpass одновременно с другими)
+	RetryMin time.Duration `yaml:"retry_min"`
+	RetryMax time.Duration `yaml:"retry_max"`
+}
+
+// ATMSourceConfig - один источник банкоматов
+type ATMSourceConfig struct {
+	Type string `yaml:"type"`
+	Role string `yaml:"role"`
+	// Path - файл для survey
+	Path string `yaml:"path"`
+}
+
+// Key - уникальный ключ источника: тип, а для файловых источников - тип:путь
+// (источников survey может быть несколько)
+func (s ATMSourceConfig) Key() string {
+	if s.Path == "" {
+		return s.Type
+	}
+	return s.Type + ":" + s.Path
+}
+
+// TrafficConfig - импорт CSV с трафиком 2GIS
+type TrafficConfig struct {
+	// Backend - где пересекать сегменты с зонами и хранить трафик
+	Backend string        `yaml:"backend"`
+	CSVPath string        `yaml:"csv_path"`
+	Timeout time.Duration `yaml:"timeout"`
+	// BatchSize - строк в одной пачке COPY
+	BatchSize int `yaml:"batch_size"`
+	// MaxRejectRate - доля отброшенных строк (0..1), выше которой импорт отменяется
+	MaxRejectRate float64 `yaml:"max_reject_rate"`
+	// ScoreMethod - как сырой трафик зон переводится в traffic_score 1..100
+	ScoreMethod string `yaml:"score_method"`
+	// UploadDir - куда сохраняются файлы, загруженные через POST /api/v1/imports/traffic
+	UploadDir string `yaml:"upload_dir"`
+	// MaxUploadMB - предельный размер загружаемого файла
+	MaxUploadMB int `yaml:"max_upload_mb"`
+	// UploadTimeout - сколько можно принимать тело запроса (ReadTimeout сервера для загрузки мал)
+	UploadTimeout time.Duration `yaml:"upload_timeout"`
+	// ForceReimport - загружать файл, даже если такой же уже импортирован (см. import_runs)
+	ForceReimport bool `yaml:"force_reimport"`
+	// Schema - формат CSV от поставщика данных
+	Schema TrafficSchema `yaml:"schema"`
+}
+
+// Форматы геометрии в CSV трафика
+const (
+	GeometryWKT    = "wkt"     // LINESTRING(71.43 51.12, ...)
+	GeometryWKBHex = "wkb_hex" // 0102000020E6100000...
+	GeometryLatLon = "latlon"  // 51.12 71.43; 51.13 71.44
+)
+
+// Хранилища трафика
+const (
+	TrafficBackendPostgres = "postgres" // PostGIS: ST_Intersects, geo_traffic_zones
+	TrafficBackendMemory   = "memory"   // в памяти, без PostGIS (демо, ноутбук); зоны - ячейки grid
+)
+
+// Нормализация traffic_score
+const (
+	ScorePercentile = "percentile" // по рангу зоны среди всех зон (равномерно 1..100)
+	ScoreLinear     = "linear"     // пропорционально трафику самой оживленной зоны
+	ScoreLog        = "log"        // как linear, но по логарифму (сглаживает выбросы)
+)
+
+// Кодировки CSV трафика
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingWindows1251 = "windows-1251"
+)
+
+// TrafficSchema - разметка CSV: колонки ищутся по заголовку, а не по номеру
+type TrafficSchema struct {
+	Delimiter        string `yaml:"delimiter"`
+	Encoding         string `yaml:"encoding"`
+	DecimalSeparator string `yaml:"decimal_separator"`
+	GeometryFormat   string `yaml:"geometry_format"`
+	// ListSeparator - разделитель 24 значений в колонках почасового профиля
+	ListSeparator string         `yaml:"list_separator"`
+	Columns       TrafficColumns `yaml:"columns"`
+}
+
+// TrafficColumns - допустимые имена заголовков для каждой колонки (без учета регистра).
+// WeekendTraffic и почасовые профили необязательны: если колонки нет в файле,
+// выходной трафик равен будничному, а профиль считается равномерным.
+type TrafficColumns struct {
+	EdgeID         []string `yaml:"edge_id"`
+	WeekdayTraffic []string `yaml:"weekday_traffic"`
+	WeekendTraffic []string `yaml:"weekend_traffic"`
+	WeekdayHourly  []string `yaml:"weekday_hourly"`
+	WeekendHourly  []string `yaml:"weekend_hourly"`
+	Geometry       []string `yaml:"geometry"`
+}
+
+// OperationsConfig - импорт выгрузок процессинга (транзакции, загрузки, обслуживание)
+type OperationsConfig struct {
+	Dir     string        `yaml:"dir"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// GridConfig - гексагональная сетка тепловой карты (покрывает bbox каждого города)
+type GridConfig struct {
+	// CellRadius - радиус гексагона в градусах широты
+	CellRadius float64 `yaml:"cell_radius"`
+	// Aspect - растяжение по долготе, чтобы гексагоны были правильными на карте.
+	// 0 - считать по широте центра города (1/cos(широты))
+	Aspect float64 `yaml:"aspect"`
+	// MinWeight - ячейки с меньшим весом не выводятся
+	MinWeight float64 `yaml:"min_weight"`
+}
+
+// BankConfig - банк-владелец сервиса (его банкоматы отделяются от конкурентов)
+type BankConfig struct {
+	// ID - id банка в справочнике brands, по нему узнаем свои банкоматы
+	ID   string `yaml:"id"`
+	Name string `yaml:"name"`
+	// MatchRadiusM - радиус сопоставления точки OSM с терминалом из справочника
+	MatchRadiusM float64 `yaml:"match_radius_m"`
+}
+
+// HolidaysConfig - праздничные дни Казахстана для правил PH в opening_hours
+type HolidaysConfig struct {
+	// Fixed - ежегодные праздники, ММ-ДД
+	Fixed []string `yaml:"fixed"`
+	// Dates - разовые выходные, ГГГГ-ММ-ДД: Курбан айт (по лунному календарю)
+	// и переносы выходных по постановлению правительства
+	Dates []string `yaml:"dates"`
+}
+
+// BrandsConfig - справочник банков: бренды из OSM и файлов приводятся к id банка
+type BrandsConfig struct {
+	// FuzzyMaxDistance - допустимая доля опечаток (расстояние Левенштейна к длине
+	// названия) при нечетком сравнении; 0 - только точные совпадения
+	FuzzyMaxDistance float64       `yaml:"fuzzy_max_distance"`
+	Banks            []BrandConfig `yaml:"banks"`
+}
+
+// BrandConfig - банк и все его написания
+type BrandConfig struct {
+	ID   string `yaml:"id"`
+	Name string `yaml:"name"`
+	// Wikidata - значения тега brand:wikidata / operator:wikidata (Q...)
+	Wikidata []string `yaml:"wikidata"`
+	// Aliases - названия на латинице и кириллице; "банк", "АО", "JSC" и т.п. можно не писать
+	Aliases []string `yaml:"aliases"`
+}
+
+// Default - настройки по умолчанию (Астана, Алматы, Шымкент, локальный Postgres)
+func Default() Config {
+	return Config{
+		Cities: []CityConfig{
+			{
+				ID:       "astana",
+				Name:     "Астана",
+				BBox:     BBox{MinLat: 51.00, MinLng: 71.30, MaxLat: 51.30, MaxLng: 71.65},
+				Center:   LatLng{Lat: 51.13, Lng: 71.43},
+				Timezone: "Asia/Almaty",
+			},
+			{
+				ID:       "almaty",
+				Name:     "Алматы",
+				BBox:     BBox{MinLat: 43.15, MinLng: 76.75, MaxLat: 43.40, MaxLng: 77.10},
+				Center:   LatLng{Lat: 43.238, Lng: 76.945},
+				Timezone: "Asia/Almaty",
+			},
+			{
+				ID:       "shymkent",
+				Name:     "Шымкент",
+				BBox:     BBox{MinLat: 42.22, MinLng: 69.45, MaxLat: 42.42, MaxLng: 69.75},
+				Center:   LatLng{Lat: 42.315, Lng: 69.587},
+				Timezone: "Asia/Almaty",
+			},
+		},
+		DefaultCity: "astana",
+		Server: ServerConfig{
+			Port:         8080,
+			CORSOrigin:   "*",
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 60 * time.Second,
+		},
+		DB: DBConfig{
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			Password:        "secret",
+			Name:            "atm_db",
+			SSLMode:         "disable",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+		},
+		OSM: OSMConfig{
+			Source:       OSMSourceOverpass,
+			OverpassURL:  "https://overpass-api.de/api/interpreter",
+			Timeout:      10 * time.Second,
+			DedupRadiusM: 30,
+		},
+		ATMSources: ATMSourcesConfig{
+			Sources:           []ATMSourceConfig{{Type: ATMSourceOSM, Role: ATMRoleAll}},
+			MergeRadiusM:      30,
+			Snapshots:         true,
+			SnapshotRetention: 90 * 24 * time.Hour,
+			MoveThresholdM:    25,
+			Refresh: RefreshConfig{
+				Interval: 6 * time.Hour,
+				RetryMin: 30 * time.Second,
+				RetryMax: 30 * time.Minute,
+			},
+			Store:     DatasetStoreFile,
+			StorePath: "./data/atms",
+		},
+		Traffic: TrafficConfig{
+			Backend:       TrafficBackendPostgres,
+			CSVPath:       "./traffic_data.csv",
+			Timeout:       10 * time.Minute,
+			BatchSize:     5000,
+			MaxRejectRate: 0.05,
+			ScoreMethod:   ScorePercentile,
+			UploadDir:     "./data/uploads",
+			MaxUploadMB:   512,
+			UploadTimeout: 10 * time.Minute,
+			Schema: TrafficSchema{
+				Delimiter:        ",",
+				Encoding:         EncodingUTF8,
+				DecimalSeparator: ".",
+				GeometryFormat:   GeometryWKT,
+				ListSeparator:    "|",
+				Columns: TrafficColumns{
+					EdgeID:         []string{"edge_id", "id"},
+					WeekdayTraffic: []string{"weekday_traffic", "traffic"},
+					WeekendTraffic: []string{"weekend_traffic"},
+					WeekdayHourly:  []string{"weekday_hourly", "weekday_profile"},
+					WeekendHourly:  []string{"weekend_hourly", "weekend_profile"},
+					Geometry:       []string{"geometry", "geom", "wkt"},
+				},
+			},
+		},
+		Operations: OperationsConfig{
+			Dir:     "./data",
+			Timeout: 5 * time.Minute,
+		},
+		Grid: GridConfig{
+			CellRadius: 0.002,
+			Aspect:     0,
+			MinWeight:  0.05,
+		},
+		Bank: BankConfig{
+			ID:           "forte",
+			Name:         "Forte Bank",
+			MatchRadiusM: 50,
+		},
+		Brands: BrandsConfig{
+			FuzzyMaxDistance: 0.2,
+			Banks: []BrandConfig{
+				{ID: "forte", Name: "ForteBank", Aliases: []string{"Forte", "ForteBank", "Форте", "ФортеБанк", "Альянс банк", "Alliance Bank"}},
+				{ID: "halyk", Name: "Halyk Bank", Aliases: []string{"Halyk", "Халык", "Народный банк", "Halyk Bank of Kazakhstan"}},
+				{ID: "kaspi", Name: "Kaspi Bank", Aliases: []string{"Kaspi", "Kaspi.kz", "Каспи", "Каспий банк"}},
+				{ID: "jusan", Name: "Jusan Bank", Aliases: []string{"Jusan", "Жусан", "Цеснабанк", "Tsesnabank", "First Heartland Jusan"}},
+				{ID: "bcc", Name: "Bank CenterCredit", Aliases: []string{"BCC", "БЦК", "CenterCredit", "ЦентрКредит", "Банк ЦентрКредит"}},
+				{ID: "eurasian", Name: "Eurasian Bank", Aliases: []string{"Eurasian", "Евразийский", "Евразийский банк"}},
+				{ID: "bereke", Name: "Bereke Bank", Aliases: []string{"Bereke", "Береке", "Сбербанк", "Sberbank", "Сбер"}},
+				{ID: "home_credit", Name: "Home Credit Bank", Aliases: []string{"Home Credit", "Хоум Кредит"}},
+				{ID: "freedom", Name: "Freedom Bank", Aliases: []string{"Freedom", "Freedom Finance", "Фридом"}},
+				{ID: "rbk", Name: "Bank RBK", Aliases: []string{"RBK", "Bank RBK", "Банк РБК"}},
+				{ID: "otbasy", Name: "Otbasy Bank", Aliases: []string{"Otbasy", "Отбасы", "Жилстройсбербанк"}},
+				{ID: "vtb", Name: "ВТБ Казахстан", Aliases: []string{"VTB", "ВТБ"}},
+				{ID: "altyn", Name: "Altyn Bank", Aliases: []string{"Altyn", "Алтын"}},
+				{ID: "nurbank", Name: "Nurbank", Aliases: []string{"Nurbank", "Нурбанк"}},
+				{ID: "shinhan", Name: "Shinhan Bank", Aliases: []string{"Shinhan", "Шинхан"}},
+				{ID: "kazpost", Name: "Казпочта", Aliases: []string{"Kazpost", "Казпочта", "Қазпошта"}},
+			},
+		},
+		Holidays: HolidaysConfig{
+			// Новый год, Рождество, 8 Марта, Наурыз, единство народа, защитник Отечества,
+			// Победа, День столицы, Конституции, Республики, Независимости
+			Fixed: []string{
+				"01-01", "01-02", "01-07", "03-08", "03-21", "03-22", "03-23",
+				"05-01", "05-07", "05-09", "07-06", "08-30", "10-25", "12-16",
+			},
+		},
+	}
+}
+
+// City - город по id
+func (c Config) City(id string) (CityConfig, bool) {
+	for _, city := range c.Cities {
+		if city.ID == id {
+			return city, true
+		}
+	}
+	return CityConfig{}, false
+}
+
+// Load читает YAML поверх настроек по умолчанию, применяет переменные
+// окружения и валидирует результат. Пустой path - CONFIG_PATH или DefaultPath.
+// Отсутствующий файл не ошибка: тогда работают только дефолты и ENV.
+func Load(path string) (Config, error) {
+	if path == "" {
+		path = os.Getenv("CONFIG_PATH")
+	}
+	if path == "" {
+		path = DefaultPath
+	}
+
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return Config{}, fmt.Errorf("ошибка разбора %s: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		fmt.Printf("⚠️ Конфиг %s не найден, используются значения по умолчанию\n", path)
+	default:
+		return Config{}, fmt.Errorf("ошибка чтения %s: %w", path, err)
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("некорректный конфиг %s:\n%w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// envOverride - переменная окружения и поле конфига, которое она переопределяет
+type envOverride struct {
+	key   string
+	apply func(cfg *Config, value string) error
+}
+
+// Переменные окружения имеют приоритет над YAML.
+// Имена DB_* совпадают с теми, что уже прописаны в docker-compose.
+var envOverrides = []envOverride{
+	{"DEMO_MODE", setBool(func(c *Config) *bool { return &c.DemoMode })},
+	{"DEFAULT_CITY", setString(func(c *Config) *string { return &c.DefaultCity })},
+
+	{"SERVER_PORT", setInt(func(c *Config) *int { return &c.Server.Port })},
+	{"SERVER_CORS_ORIGIN", setString(func(c *Config) *string { return &c.Server.CORSOrigin })},
+
+	{"DB_HOST", setString(func(c *Config) *string { return &c.DB.Host })},
+	{"DB_PORT", setInt(func(c *Config) *int { return &c.DB.Port })},
+	{"DB_USER", setString(func(c *Config) *string { return &c.DB.User })},
+	{"DB_PASSWORD", setString(func(c *Config) *string { return &c.DB.Password })},
+	{"DB_NAME", setString(func(c *Config) *string { return &c.DB.Name })},
+	{"DB_SSLMODE", setString(func(c *Config) *string { return &c.DB.SSLMode })},
+	{"DB_MAX_OPEN_CONNS", setInt(func(c *Config) *int { return &c.DB.MaxOpenConns })},
+	{"DB_MAX_IDLE_CONNS", setInt(func(c *Config) *int { return &c.DB.MaxIdleConns })},
+	{"DB_AUTO_MIGRATE", setBool(func(c *Config) *bool { return &c.DB.AutoMigrate })},
+
+	{"OSM_SOURCE", setString(func(c *Config) *string { return &c.OSM.Source })},
+	{"OSM_FILE_PATH", setString(func(c *Config) *string { return &c.OSM.FilePath })},
+	{"ATM_SNAPSHOTS", setBool(func(c *Config) *bool { return &c.ATMSources.Snapshots })},
+	{"OSM_OVERPASS_URL", setString(func(c *Config) *string { return &c.OSM.OverpassURL })},
+	{"OSM_TIMEOUT", setDuration(func(c *Config) *time.Duration { return &c.OSM.Timeout })},
+	{"ATM_STORE", setString(func(c *Config) *string { return &c.ATMSources.Store })},
+	{"ATM_REFRESH_INTERVAL", setDuration(func(c *Config) *time.Duration { return &c.ATMSources.Refresh.Interval })},
+
+	{"TRAFFIC_BACKEND", setString(func(c *Config) *string { return &c.Traffic.Backend })},
+	{"TRAFFIC_CSV_PATH", setString(func(c *Config) *string { return &c.Traffic.CSVPath })},
+	{"TRAFFIC_SCORE_METHOD", setString(func(c *Config) *string { return &c.Traffic.ScoreMethod })},
+	{"TRAFFIC_UPLOAD_DIR", setString(func(c *Config) *string { return &c.Traffic.UploadDir })},
+	{"TRAFFIC_FORCE_REIMPORT", setBool(func(c *Config) *bool { return &c.Traffic.ForceReimport })},
+	{"OPERATIONS_DIR", setString(func(c *Config) *string { return &c.Operations.Dir })},
+
+	{"BANK_ID", setString(func(c *Config) *string { return &c.Bank.ID })},
+	{"BANK_NAME", setString(func(c *Config) *string { return &c.Bank.Name })},
+}
+
+func applyEnv(cfg *Config) error {
+	for _, o := range envOverrides {
+		value, ok := os.LookupEnv(o.key)
+		if !ok {
+			continue
+		}
+		if err := o.apply(cfg, value); err != nil {
+			return fmt.Errorf("переменная %s=%q: %w", o.key, value, err)
+		}
+	}
+	return nil
+}
+
+func setString(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func setInt(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("ожидается целое число")
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func setBool(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("ожидается true/false")
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func setDuration(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("ожидается длительность вида 10s, 5m")
+		}
+		*field(c) = d
+		return nil
+	}
+}
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"geocash/internal/geo"
+)
+
+// Validate проверяет конфиг и возвращает все найденные ошибки сразу
+func (c Config) Validate() error {
+	var v validator
+
+	v.cities(c.Cities)
+	if _, ok := c.City(c.DefaultCity); !ok {
+		v.add("default_city", "города %q нет в cities", c.DefaultCity)
+	}
+
+	v.check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port", "должен быть в диапазоне 1..65535, получено %d", c.Server.Port)
+	v.check(c.Server.ReadTimeout > 0, "server.read_timeout", "должен быть больше нуля")
+	v.check(c.Server.WriteTimeout > 0, "server.write_timeout", "должен быть больше нуля")
+
+	v.check(c.DB.Host != "", "db.host", "не задан")
+	v.check(c.DB.Port > 0 && c.DB.Port <= 65535, "db.port", "должен быть в диапазоне 1..65535, получено %d", c.DB.Port)
+	v.check(c.DB.User != "", "db.user", "не задан")
+	v.check(c.DB.Name != "", "db.dbname", "не задан")
+	v.check(c.DB.MaxOpenConns >= 0, "db.max_open_conns", "не может быть отрицательным")
+	v.check(c.DB.MaxIdleConns >= 0, "db.max_idle_conns", "не может быть отрицательным")
+	v.check(c.DB.MaxOpenConns == 0 || c.DB.MaxIdleConns <= c.DB.MaxOpenConns,
+		"db.max_idle_conns", "не может превышать max_open_conns (%d > %d)", c.DB.MaxIdleConns, c.DB.MaxOpenConns)
+	switch c.DB.SSLMode {
+	case "disable", "require", "verify-ca", "verify-full":
+	default:
+		v.add("db.sslmode", "допустимо disable, require, verify-ca, verify-full, получено %q", c.DB.SSLMode)
+	}
+
+	switch c.OSM.Source {
+	case OSMSourceOverpass:
+	case OSMSourceFile:
+		v.check(c.OSM.FilePath != "", "osm.file_path", "обязателен для source: file")
+	default:
+		v.add("osm.source", "допустимо overpass или file, получено %q", c.OSM.Source)
+	}
+	v.url("osm.overpass_url", c.OSM.OverpassURL)
+	for i, mirror := range c.OSM.OverpassMirrors {
+		v.url(fmt.Sprintf("osm.overpass_mirrors[%d]", i), mirror)
+	}
+	v.check(c.OSM.Timeout > 0, "osm.timeout", "должен быть больше нуля")
+	v.check(c.OSM.DedupRadiusM >= 0, "osm.dedup_radius_m", "не может быть отрицательным")
+
+	v.atmSources(c.ATMSources)
+
+	switch c.Traffic.Backend {
+	case TrafficBackendPostgres, TrafficBackendMemory:
+	default:
+		v.add("traffic.backend", "допустимо %s, %s, получено %q", TrafficBackendPostgres, TrafficBackendMemory, c.Traffic.Backend)
+	}
+	v.check(c.Traffic.CSVPath != "", "traffic.csv_path", "не задан")
+	v.check(c.Traffic.Timeout > 0, "traffic.timeout", "должен быть больше нуля")
+	v.check(c.Traffic.BatchSize > 0, "traffic.batch_size", "должен быть больше нуля")
+	v.check(c.Traffic.MaxRejectRate >= 0 && c.Traffic.MaxRejectRate <= 1, "traffic.max_reject_rate", "должен быть в диапазоне 0..1, получено %g", c.Traffic.MaxRejectRate)
+	switch c.Traffic.ScoreMethod {
+	case ScorePercentile, ScoreLinear, ScoreLog:
+	default:
+		v.add("traffic.score_method", "допустимо %s, %s, %s, получено %q", ScorePercentile, ScoreLinear, ScoreLog, c.Traffic.ScoreMethod)
+	}
+	v.check(c.Traffic.UploadDir != "", "traffic.upload_dir", "не задан")
+	v.check(c.Traffic.MaxUploadMB > 0, "traffic.max_upload_mb", "должен быть больше нуля")
+	v.check(c.Traffic.UploadTimeout > 0, "traffic.upload_timeout", "должен быть больше нуля")
+	v.trafficSchema(c.Traffic.Schema)
+	v.check(c.Operations.Dir != "", "operations.dir", "не задан")
+	v.check(c.Operations.Timeout > 0, "operations.timeout", "должен быть больше нуля")
+
+	v.check(c.Grid.CellRadius > 0 && c.Grid.CellRadius < 1, "grid.cell_radius", "должен быть в диапазоне (0, 1) градуса, получено %g", c.Grid.CellRadius)
+	v.check(c.Grid.Aspect >= 0, "grid.aspect", "не может быть отрицательным (0 - по широте города)")
+	v.check(c.Grid.MinWeight >= 0 && c.Grid.MinWeight <= 1, "grid.min_weight", "должен быть в диапазоне 0..1")
+
+	v.check(c.Bank.ID != "", "bank.id", "не задан")
+	v.check(c.Bank.Name != "", "bank.name", "не задан")
+	v.check(c.Bank.MatchRadiusM > 0, "bank.match_radius_m", "должен быть больше нуля")
+
+	v.brands(c.Brands, c.Bank.ID)
+	v.holidays(c.Holidays)
+
+	return v.err()
+}
+
+// validator копит ошибки вида "поле: причина"
+type validator struct {
+	errs []error
+}
+
+func (v *validator) add(field, format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) check(ok bool, field, format string, args ...interface{}) {
+	if !ok {
+		v.add(field, format, args...)
+	}
+}
+
+func (v *validator) bbox(field string, b BBox) {
+	v.check(b.MinLat >= -90 && b.MaxLat <= 90, field, "широта вне диапазона -90..90")
+	v.check(b.MinLng >= -180 && b.MaxLng <= 180, field, "долгота вне диапазона -180..180")
+	v.check(b.MinLat < b.MaxLat, field, "min_lat (%g) должен быть меньше max_lat (%g)", b.MinLat, b.MaxLat)
+	v.check(b.MinLng < b.MaxLng, field, "min_lng (%g) должен быть меньше max_lng (%g)", b.MinLng, b.MaxLng)
+}
+
+func (v *validator) cities(cities []CityConfig) {
+	v.check(len(cities) > 0, "cities", "не задано ни одного города")
+	seen := make(map[string]bool)
+	for i, c := range cities {
+		field := fmt.Sprintf("cities[%d]", i)
+		if c.ID != "" {
+			field = fmt.Sprintf("cities[%s]", c.ID)
+		}
+		v.check(idPattern.MatchString(c.ID), field+".id", "допустимы латинские буквы в нижнем регистре, цифры и '_', получено %q", c.ID)
+		v.check(len(c.ID) <= maxCityIDLen, field+".id", "не длиннее %d символов (city_id в БД)", maxCityIDLen)
+		v.check(!seen[c.ID], field+".id", "повторяется")
+		seen[c.ID] = true
+		v.check(c.Name != "", field+".name", "не задано")
+		v.bbox(field+".bbox", c.BBox)
+		v.check(c.Center.Lat >= c.BBox.MinLat && c.Center.Lat <= c.BBox.MaxLat &&
+			c.Center.Lng >= c.BBox.MinLng && c.Center.Lng <= c.BBox.MaxLng,
+			field+".center", "должен лежать внутри bbox")
+		if _, err := time.LoadLocation(c.Timezone); err != nil || c.Timezone == "" {
+			v.add(field+".timezone", "неизвестный часовой пояс %q", c.Timezone)
+		}
+	}
+}
+
+func (v *validator) url(field, value string) {
+	if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+		v.add(field, "некорректный URL %q", value)
+	}
+}
+
+// maxCityIDLen - длина city_id в таблицах (VARCHAR(50)); zone_code рассчитан на нее
+const maxCityIDLen = 50
+
+// idPattern - id города (в URL и кодах зон) и банка
+var idPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+func (v *validator) atmSources(c ATMSourcesConfig) {
+	v.check(len(c.Sources) > 0, "atm_sources.sources", "не задано ни одного источника")
+	v.check(c.MergeRadiusM >= 0, "atm_sources.merge_radius_m", "не может быть отрицательным")
+	v.check(c.MoveThresholdM >= 0, "atm_sources.move_threshold_m", "не может быть отрицательным")
+	v.check(c.SnapshotRetention >= 0, "atm_sources.snapshot_retention", "не может быть отрицательным (0 - не удалять)")
+	v.check(c.Refresh.Interval > 0, "atm_sources.refresh.interval", "должен быть больше нуля")
+	v.check(c.Refresh.RetryMin > 0, "atm_sources.refresh.retry_min", "должен быть больше нуля")
+	v.check(c.Refresh.RetryMax >= c.Refresh.RetryMin, "atm_sources.refresh.retry_max",
+		"не может быть меньше retry_min (%s < %s)", c.Refresh.RetryMax, c.Refresh.RetryMin)
+	switch c.Store {
+	case DatasetStoreFile:
+		v.check(c.StorePath != "", "atm_sources.store_path", "обязателен для store: file")
+	case DatasetStorePostgres, DatasetStoreOff:
+	default:
+		v.add("atm_sources.store", "допустимо %s, %s или %s, получено %q", DatasetStoreFile, DatasetStorePostgres, DatasetStoreOff, c.Store)
+	}
+	seen := make(map[string]bool)
+	for i, s := range c.Sources {
+		field := fmt.Sprintf("atm_sources.sources[%d]", i)
+		switch s.Type {
+		case ATMSourceOSM, ATMSourceRegistry:
+		case ATMSourceSurvey:
+			v.check(s.Path != "", field+".path", "обязателен для survey")
+		default:
+			v.add(field+".type", "допустимо osm, registry или survey, получено %q", s.Type)
+		}
+		switch s.Role {
+		case ATMRoleOwn, ATMRoleCompetitors, ATMRoleAll:
+		default:
+			v.add(field+".role", "допустимо own, competitors или all, получено %q", s.Role)
+		}
+		v.check(!seen[s.Key()], field, "источник %s указан дважды", s.Key())
+		seen[s.Key()] = true
+	}
+}
+
+func (v *validator) brands(c BrandsConfig, ownID string) {
+	v.check(c.FuzzyMaxDistance >= 0 && c.FuzzyMaxDistance < 0.5, "brands.fuzzy_max_distance", "должен быть в диапазоне 0..0.5")
+	seen := make(map[string]bool)
+	for i, b := range c.Banks {
+		field := fmt.Sprintf("brands.banks[%d]", i)
+		v.check(idPattern.MatchString(b.ID), field+".id", "допустимы латинские буквы в нижнем регистре, цифры и '_', получено %q", b.ID)
+		v.check(!seen[b.ID], field+".id", "банк %q указан дважды", b.ID)
+		seen[b.ID] = true
+		v.check(b.Name != "", field+".name", "не задан")
+	}
+	v.check(seen[ownID], "bank.id", "банка %q нет в brands.banks", ownID)
+}
+
+func (v *validator) holidays(c HolidaysConfig) {
+	for i, d := range c.Fixed {
+		_, err := time.Parse("01-02", d)
+		v.check(err == nil, fmt.Sprintf("holidays.fixed[%d]", i), "ожидается ММ-ДД, получено %q", d)
+	}
+	for i, d := range c.Dates {
+		_, err := time.Parse(time.DateOnly, d)
+		v.check(err == nil, fmt.Sprintf("holidays.dates[%d]", i), "ожидается ГГГГ-ММ-ДД, получено %q", d)
+	}
+}
+
+func (v *validator) trafficSchema(s TrafficSchema) {
+	v.check(utf8.RuneCountInString(s.Delimiter) == 1 && s.Delimiter != "\"" && s.Delimiter != "\n",
+		"traffic.schema.delimiter", "должен быть одним символом (например \",\", \";\", \"\\t\"), получено %q", s.Delimiter)
+	switch s.Encoding {
+	case EncodingUTF8, EncodingWindows1251:
+	default:
+		v.add("traffic.schema.encoding", "допустимо %s, %s, получено %q", EncodingUTF8, EncodingWindows1251, s.Encoding)
+	}
+	v.check(s.DecimalSeparator == "." || s.DecimalSeparator == ",",
+		"traffic.schema.decimal_separator", "допустимо \".\" или \",\", получено %q", s.DecimalSeparator)
+	v.check(s.DecimalSeparator != s.Delimiter,
+		"traffic.schema.decimal_separator", "совпадает с разделителем колонок")
+	v.check(utf8.RuneCountInString(s.ListSeparator) == 1 && s.ListSeparator != s.Delimiter && s.ListSeparator != s.DecimalSeparator,
+		"traffic.schema.list_separator", "должен быть одним символом, отличным от разделителя колонок и десятичного, получено %q", s.ListSeparator)
+	switch s.GeometryFormat {
+	case GeometryLatLon:
+		// Точки в ячейке разделены ';' или '|': с таким же разделителем колонок
+		// геометрия развалится на колонки
+		v.check(!strings.Contains(geo.LatLonPairSeparators, s.Delimiter),
+			"traffic.schema.delimiter", "%q разделяет точки в geometry_format: %s, выберите другой разделитель колонок", s.Delimiter, GeometryLatLon)
+	case GeometryWKT, GeometryWKBHex:
+	default:
+		v.add("traffic.schema.geometry_format", "допустимо %s, %s, %s, получено %q", GeometryWKT, GeometryWKBHex, GeometryLatLon, s.GeometryFormat)
+	}
+	v.check(len(s.Columns.EdgeID) > 0, "traffic.schema.columns.edge_id", "не задано ни одного имени заголовка")
+	v.check(len(s.Columns.WeekdayTraffic) > 0, "traffic.schema.columns.weekday_traffic", "не задано ни одного имени заголовка")
+	v.check(len(s.Columns.Geometry) > 0, "traffic.schema.columns.geometry", "не задано ни одного имени заголовка")
+}
+
+func (v *validator) err() error {
+	return errors.Join(v.errs...)
+}
@@ -0,0 +1,85 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"geocash/internal/domain/city"
+	"geocash/internal/domain/terminal"
+)
+
+// Restore загружает сохраненные наборы банкоматов всех городов.
+// Вызывается до первого обновления: пока источники не ответят, карта
+// показывает данные прошлого запуска, а не пустоту.
+func (s *Service) Restore(ctx context.Context) {
+	if s.datasets == nil {
+		return
+	}
+	for _, c := range s.cities.All() {
+		d, err := s.datasets.LoadDataset(ctx, c.ID)
+		if errors.Is(err, terminal.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			fmt.Printf("⚠️ Не удалось загрузить сохраненные банкоматы (%s): %v\n", c.Name, err)
+			continue
+		}
+
+		s.mu.Lock()
+		s.cache[c.ID] = cityATMs{forte: d.Forte, competitors: d.Competitors, updatedAt: d.UpdatedAt}
+		s.mu.Unlock()
+		s.publishTerminals(c, d.Forte)
+		s.lastGood[c.ID] = s.configuredSources(d.Sources)
+		fmt.Printf("💾 Загружены банкоматы (%s) от %s: %d Forte, %d Competitors\n",
+			c.Name, d.UpdatedAt.In(c.Location).Format("02.01.2006 15:04"), len(d.Forte), len(d.Competitors))
+	}
+}
+
+// configuredSources - сохраненные ответы только тех источников, что есть в конфиге
+// (источник могли убрать или переименовать файл обхода)
+func (s *Service) configuredSources(saved map[string]terminal.SourceResponse) map[string]terminal.SourceResponse {
+	out := make(map[string]terminal.SourceResponse)
+	for _, src := range s.sources {
+		if resp, ok := saved[src.Key]; ok {
+			out[src.Key] = resp
+		}
+	}
+	return out
+}
+
+// saveDataset сохраняет набор города вместе с последними ответами источников
+func (s *Service) saveDataset(ctx context.Context, c city.City, data cityATMs) {
+	if s.datasets == nil {
+		return
+	}
+	d := terminal.Dataset{
+		Format:      terminal.DatasetFormat,
+		CityID:      c.ID,
+		UpdatedAt:   data.updatedAt,
+		Forte:       data.forte,
+		Competitors: data.competitors,
+		Sources:     s.lastGood[c.ID],
+	}
+	if err := s.datasets.SaveDataset(ctx, d); err != nil {
+		fmt.Printf("⚠️ Не удалось сохранить банкоматы (%s): %v\n", c.Name, err)
+	}
+}
+
+// dataAsOf - время данных в часовом поясе города; nil - данных из источников еще нет
+func dataAsOf(updatedAt time.Time, c city.City) *time.Time {
+	if updatedAt.IsZero() {
+		return nil
+	}
+	t := updatedAt.In(c.Location)
+	return &t
+}
+
+// dataAge - возраст данных в секундах
+func dataAge(updatedAt time.Time) int64 {
+	if updatedAt.IsZero() {
+		return 0
+	}
+	return int64(time.Since(updatedAt).Seconds())
+}
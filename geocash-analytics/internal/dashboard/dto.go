@@ -1,12 +1,36 @@
 package dashboard
 
 import (
+	"time"
+
 	"geocash/internal/analytics"
+	"geocash/internal/domain/city"
 	"geocash/internal/domain/terminal"
+	"geocash/internal/domain/traffic"
 )
 
 type DashboardResponse struct {
+	City        city.City                          `json:"city"`
+	DataAsOf    *time.Time                         `json:"dataAsOf,omitempty"` // когда банкоматы получены из источников
+	DataAgeSec  int64                              `json:"dataAgeSec"`         // возраст банкоматов, секунды
 	Forte       []terminal.ATM                     `json:"forte"`
 	Competitors []terminal.ATM                     `json:"competitors"`
 	HeatmapGrid analytics.GeoJSONFeatureCollection `json:"heatmapGrid"`
+	TimeSlice   traffic.TimeSlice                  `json:"timeSlice"` // срез, по которому построена тепловая карта
+	Filter      terminal.Filter                    `json:"filter"`    // фильтр банкоматов по атрибутам
+	// Availability - при запросе с at: сколько банкоматов работает в этот момент
+	Availability *Availability `json:"availability,omitempty"`
+	// Provenance - есть ли в ответе оценочные или сгенерированные данные
+	Provenance ResponseProvenance `json:"provenance"`
+}
+
+// HeatmapNone - тепловой карты нет: трафик не загружен, а условная сетка только в демо-режиме
+const HeatmapNone = "none"
+
+// ResponseProvenance - происхождение данных ответа
+type ResponseProvenance struct {
+	Kind    string         `json:"kind"`    // худшее по банкоматам и тепловой карте
+	Sources []string       `json:"sources"` // источники банкоматов в ответе
+	ATMs    map[string]int `json:"atms"`    // банкоматов по Provenance.Kind
+	Heatmap string         `json:"heatmap"` // real, synthetic или none
 }
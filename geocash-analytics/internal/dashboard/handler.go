@@ -1,10 +1,20 @@
 package dashboard
 
 import (
-	"encoding/json"
 	"net/http"
+	"time"
+
+	"geocash/internal/api"
+	"geocash/internal/domain/brand"
+	"geocash/internal/domain/city"
+	"geocash/internal/domain/terminal"
+	"geocash/internal/domain/traffic"
+	"geocash/internal/openinghours"
 )
 
+// Handler - GET /api/v1/dashboard?city=almaty&day=weekday&hours=7-10.
+// Банкоматы отбираются по атрибутам: open24x7, cash_in, currency, wheelchair, indoor, level
+// (см. terminal.ParseFilter), а с at=2026-10-18T23:00 - только работающие в этот момент.
 type Handler struct {
 	service *Service
 }
@@ -14,9 +24,64 @@ func NewHandler(service *Service) *Handler {
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+	c, err := h.service.cities.Resolve(q.Get("city"))
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	slice, err := traffic.ParseTimeSlice(q.Get("day"), q.Get("hours"))
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	filter, err := terminal.ParseFilter(q)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var at time.Time
+	if v := q.Get("at"); v != "" {
+		if at, err = openinghours.ParseAt(v, c.Location); err != nil {
+			api.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	api.WriteJSON(w, http.StatusOK, h.service.GetDashboardData(r.Context(), c, slice, filter, at))
+}
+
+// CitiesResponse - список городов для переключателя на карте
+type CitiesResponse struct {
+	Default string      `json:"default"`
+	Cities  []city.City `json:"cities"`
+}
+
+// CitiesHandler - GET /api/v1/cities
+type CitiesHandler struct {
+	cities *city.Registry
+}
+
+func NewCitiesHandler(cities *city.Registry) *CitiesHandler {
+	return &CitiesHandler{cities: cities}
+}
+
+func (h *CitiesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.WriteJSON(w, http.StatusOK, CitiesResponse{Default: h.cities.Default().ID, Cities: h.cities.All()})
+}
+
+// UnmatchedBanksHandler - GET /api/v1/banks/unmatched: названия банков из источников,
+// которых нет в справочнике brands (что добавить в aliases)
+type UnmatchedBanksHandler struct {
+	brands *brand.Dictionary
+}
+
+func NewUnmatchedBanksHandler(brands *brand.Dictionary) *UnmatchedBanksHandler {
+	return &UnmatchedBanksHandler{brands: brands}
+}
 
-	data := h.service.GetDashboardData()
-	json.NewEncoder(w).Encode(data)
+func (h *UnmatchedBanksHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.WriteJSON(w, http.StatusOK, h.brands.Unmatched())
 }
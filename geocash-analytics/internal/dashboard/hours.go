@@ -0,0 +1,86 @@
+package dashboard
+
+import (
+	"sync"
+	"time"
+
+	"geocash/internal/domain/city"
+	"geocash/internal/domain/terminal"
+	"geocash/internal/openinghours"
+)
+
+// Availability - сколько банкоматов работает в момент At.
+// Закрытые в ответ не попадают; с неизвестным режимом (нет opening_hours
+// или он не разобран) остаются - в OSM режим указан далеко не у всех.
+type Availability struct {
+	At      time.Time `json:"at"`
+	Open    int       `json:"open"`
+	Closed  int       `json:"closed"`
+	Unknown int       `json:"unknown"`
+}
+
+// schedules - разобранные opening_hours; разных строк немного, каждая разбирается один раз
+type schedules struct {
+	m sync.Map // string -> *openinghours.Schedule (nil - не разобрать)
+}
+
+func (c *schedules) get(oh string) *openinghours.Schedule {
+	if v, ok := c.m.Load(oh); ok {
+		return v.(*openinghours.Schedule)
+	}
+	s, err := openinghours.Parse(oh)
+	if err != nil {
+		s = nil
+	}
+	c.m.Store(oh, s)
+	return s
+}
+
+// status - режим банкомата в момент at (в часовом поясе города)
+func (s *Service) status(atm terminal.ATM, at time.Time) openinghours.Status {
+	if atm.OpeningHours == "" {
+		return openinghours.Unknown
+	}
+	schedule := s.schedules.get(atm.OpeningHours)
+	if schedule == nil {
+		return openinghours.Unknown
+	}
+	return schedule.Status(at, s.holidays)
+}
+
+// openAt оставляет банкоматы, работающие в момент at, и считает их в stats
+func (s *Service) openAt(atms []terminal.ATM, at time.Time, stats *Availability) []terminal.ATM {
+	out := []terminal.ATM{}
+	for _, atm := range atms {
+		status := s.status(atm, at)
+		switch status {
+		case openinghours.Open:
+			stats.Open++
+		case openinghours.Closed:
+			stats.Closed++
+			continue
+		default:
+			stats.Unknown++
+		}
+		atm.Availability = string(status)
+		out = append(out, atm)
+	}
+	return out
+}
+
+// ClosedTerminals - terminal_id наших терминалов города, закрытых в момент at
+// (режим работы берется из OSM при слиянии источников)
+func (s *Service) ClosedTerminals(c city.City, at time.Time) []string {
+	s.mu.RLock()
+	forte := s.cache[c.ID].forte
+	s.mu.RUnlock()
+
+	at = at.In(c.Location)
+	var closed []string
+	for _, atm := range forte {
+		if atm.TerminalID != "" && s.status(atm, at) == openinghours.Closed {
+			closed = append(closed, atm.TerminalID)
+		}
+	}
+	return closed
+}
@@ -0,0 +1,145 @@
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"geocash/internal/api"
+	"geocash/internal/domain/city"
+	"geocash/internal/domain/terminal"
+)
+
+// dateLayout - формат дат в параметрах from/to
+const dateLayout = "2006-01-02"
+
+// BankChanges - итог изменений сети одного банка за период
+type BankChanges struct {
+	BankID  string `json:"bankId,omitempty"`
+	Bank    string `json:"bank"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Moved   int    `json:"moved"`
+}
+
+// NetworkChangesResponse - изменения сетей банков в городе за период
+type NetworkChangesResponse struct {
+	City    city.City                `json:"city"`
+	From    string                   `json:"from"`
+	To      string                   `json:"to"`
+	Banks   []BankChanges            `json:"banks"`
+	Changes []terminal.NetworkChange `json:"changes"`
+}
+
+// NetworkHandler - GET /api/v1/network/changes?city=astana&from=2026-10-01&to=2026-10-31&bank=halyk.
+// Даты включительно, по часовому поясу города; по умолчанию - текущий месяц.
+type NetworkHandler struct {
+	snapshots terminal.SnapshotStore
+	cities    *city.Registry
+}
+
+func NewNetworkHandler(snapshots terminal.SnapshotStore, cities *city.Registry) *NetworkHandler {
+	return &NetworkHandler{snapshots: snapshots, cities: cities}
+}
+
+func (h *NetworkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	c, err := h.cities.Resolve(q.Get("city"))
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	now := time.Now().In(c.Location)
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, c.Location)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, c.Location)
+	if from, err = parseDate(q.Get("from"), from, c.Location); err != nil {
+		api.WriteError(w, http.StatusBadRequest, fmt.Errorf("параметр from: %w", err))
+		return
+	}
+	if to, err = parseDate(q.Get("to"), to, c.Location); err != nil {
+		api.WriteError(w, http.StatusBadRequest, fmt.Errorf("параметр to: %w", err))
+		return
+	}
+	if to.Before(from) {
+		api.WriteError(w, http.StatusBadRequest, errors.New("from позже to"))
+		return
+	}
+
+	changes, err := h.snapshots.NetworkChanges(r.Context(), c.ID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if bank := q.Get("bank"); bank != "" {
+		changes = filterBank(changes, bank)
+	}
+
+	api.WriteJSON(w, http.StatusOK, NetworkChangesResponse{
+		City:    c,
+		From:    from.Format(dateLayout),
+		To:      to.Format(dateLayout),
+		Banks:   summarize(changes),
+		Changes: changes,
+	})
+}
+
+func parseDate(v string, def time.Time, loc *time.Location) (time.Time, error) {
+	if v == "" {
+		return def, nil
+	}
+	t, err := time.ParseInLocation(dateLayout, v, loc)
+	if err != nil {
+		return t, fmt.Errorf("ожидается дата ГГГГ-ММ-ДД, получено %q", v)
+	}
+	return t, nil
+}
+
+func filterBank(changes []terminal.NetworkChange, bankID string) []terminal.NetworkChange {
+	out := []terminal.NetworkChange{}
+	for _, c := range changes {
+		if c.BankID == bankID {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// summarize - итоги по банкам, самые активные первыми
+func summarize(changes []terminal.NetworkChange) []BankChanges {
+	byBank := make(map[string]*BankChanges)
+	for _, c := range changes {
+		key := c.BankID
+		if key == "" {
+			key = "?" + c.Bank // неопознанные банки - по названию
+		}
+		b, ok := byBank[key]
+		if !ok {
+			b = &BankChanges{BankID: c.BankID, Bank: c.Bank}
+			byBank[key] = b
+		}
+		switch c.Change {
+		case terminal.ChangeAdded:
+			b.Added++
+		case terminal.ChangeRemoved:
+			b.Removed++
+		case terminal.ChangeMoved:
+			b.Moved++
+		}
+	}
+
+	out := make([]BankChanges, 0, len(byBank))
+	for _, b := range byBank {
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		ti, tj := out[i].Added+out[i].Removed+out[i].Moved, out[j].Added+out[j].Removed+out[j].Moved
+		if ti != tj {
+			return ti > tj
+		}
+		return out[i].Bank < out[j].Bank
+	})
+	return out
+}
@@ -0,0 +1,55 @@
+package dashboard
+
+import "geocash/internal/domain/terminal"
+
+// allowed убирает сгенерированные данные вне демо-режима: их нельзя показать
+// как факт. Сгенерированная точка отбрасывается целиком, а у реальной точки
+// со сгенерированными показателями сбрасываются только показатели.
+func (s *Service) allowed(atms []terminal.ATM) []terminal.ATM {
+	if s.demo {
+		return atms
+	}
+	out := []terminal.ATM{}
+	for _, atm := range atms {
+		if atm.Provenance.Location == terminal.ProvenanceSynthetic {
+			continue
+		}
+		if atm.Provenance.Metrics == terminal.ProvenanceSynthetic {
+			atm = withoutMetrics(atm)
+		}
+		out = append(out, atm)
+	}
+	return out
+}
+
+// withoutMetrics - копия банкомата без показателей: точка и атрибуты остаются,
+// Metrics помечаются как неизвестные
+func withoutMetrics(atm terminal.ATM) terminal.ATM {
+	atm.EstWithdrawalKZT, atm.EstDepositKZT = 0, 0
+	atm.AvgCashBalanceKZT, atm.TotalCashKZT = 0, 0
+	atm.WithdrawalFreqPerDay, atm.DowntimePct = 0, 0
+	atm.EfficiencyStatus = ""
+	atm.Cassettes, atm.Complaints = nil, nil
+	atm.Provenance.Metrics = terminal.ProvenanceUnknown
+	atm.Provenance.Resolve()
+	return atm
+}
+
+// provenance - сводка происхождения данных ответа
+func provenance(heatmap string, lists ...[]terminal.ATM) ResponseProvenance {
+	p := ResponseProvenance{Sources: []string{}, ATMs: make(map[string]int), Heatmap: heatmap}
+	kinds := []string{heatmap}
+	seen := make(map[string]bool)
+	for _, atms := range lists {
+		for _, atm := range atms {
+			p.ATMs[atm.Provenance.Kind]++
+			kinds = append(kinds, atm.Provenance.Kind)
+			if !seen[atm.Source] {
+				seen[atm.Source] = true
+				p.Sources = append(p.Sources, atm.Source)
+			}
+		}
+	}
+	p.Kind = terminal.WorstProvenance(kinds...)
+	return p
+}
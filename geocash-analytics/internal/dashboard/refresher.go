@@ -0,0 +1,83 @@
+package dashboard
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"geocash/internal/config"
+)
+
+// Run обновляет банкоматы сразу и дальше раз в cfg.Interval, пока не отменен ctx.
+// Расписание у каждого источника в каждом городе свое: если источник не ответил,
+// повторяется только он - с экспоненциальной паузой от RetryMin до RetryMax, а
+// остальные (в том числе зеркала Overpass под лимитами) не опрашиваются заново.
+// До ответа на карте остаются прошлые данные этого источника.
+func (s *Service) Run(ctx context.Context, cfg config.RefreshConfig) {
+	for {
+		wait := max(time.Until(s.refreshData(cfg)), 0)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// sourceSchedule - когда опрашивать источник в городе
+type sourceSchedule struct {
+	next     time.Time // нулевое - опросить сразу
+	failures int       // неудачных попыток подряд
+}
+
+// schedule - расписание источника source в городе cityID
+func (s *Service) schedule(cityID, source string) *sourceSchedule {
+	byCity := s.sourceSchedules[cityID]
+	if byCity == nil {
+		byCity = make(map[string]*sourceSchedule)
+		s.sourceSchedules[cityID] = byCity
+	}
+	sched := byCity[source]
+	if sched == nil {
+		sched = &sourceSchedule{}
+		byCity[source] = sched
+	}
+	return sched
+}
+
+// due - пора ли опрашивать источник
+func (sc *sourceSchedule) due(now time.Time) bool {
+	return !now.Before(sc.next)
+}
+
+// succeeded - следующий опрос через Interval
+func (sc *sourceSchedule) succeeded(now time.Time, cfg config.RefreshConfig) {
+	sc.failures = 0
+	sc.next = now.Add(cfg.Interval)
+}
+
+// failed - повтор с экспоненциальной паузой (не позже Interval); возвращает паузу
+func (sc *sourceSchedule) failed(now time.Time, cfg config.RefreshConfig) time.Duration {
+	wait := min(retryDelay(cfg, sc.failures), cfg.Interval)
+	sc.failures++
+	sc.next = now.Add(wait)
+	return wait
+}
+
+// retryDelay - пауза перед повтором после failures подряд неудачных попыток:
+// RetryMin * 2^failures, не больше RetryMax, случайно в диапазоне [d/2, d]
+func retryDelay(cfg config.RefreshConfig, failures int) time.Duration {
+	d := cfg.RetryMin
+	for range failures {
+		if d >= cfg.RetryMax/2 {
+			d = cfg.RetryMax
+			break
+		}
+		d *= 2
+	}
+	d = min(d, cfg.RetryMax)
+	return d/2 + rand.N(d/2+1)
+}
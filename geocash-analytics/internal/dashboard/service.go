@@ -1,78 +1,262 @@
 package dashboard
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"geocash/internal/analytics"
+	"geocash/internal/config"
+	"geocash/internal/domain/city"
 	"geocash/internal/domain/terminal"
-	"geocash/internal/platform/provider"
-	"strings"
+	"geocash/internal/domain/traffic"
+	"geocash/internal/geo"
+	"geocash/internal/openinghours"
+	"sync"
+	"time"
 )
 
+// TerminalIndex получает наши банкоматы после каждого обновления: хранилищу зон
+// в памяти (traffic.backend: memory) неоткуда взять справочник terminals, а зоны
+// с нашим банкоматом не кандидаты на расширение
+type TerminalIndex interface {
+	// SetTerminals заменяет наши банкоматы города: terminal_id (или id банкомата) -> точка
+	SetTerminals(cityID string, terminals map[string]geo.Point)
+}
+
 type Service struct {
-	repo terminal.Repository
-	osm  *provider.OSMProvider
-	grid *analytics.GridService
+	repo    terminal.Repository
+	sources []Source // по убыванию приоритета
+	grid    *analytics.GridService
+	zones   traffic.ZoneRepository // nil - тепловая карта только синтетическая (демо)
+	cities  *city.Registry
+	bank    config.BankConfig
+	// mergeRadiusM - банкоматы одного банка ближе этого из разных источников - один банкомат
+	mergeRadiusM float64
+	snapshots    terminal.SnapshotStore // nil - снимки не сохраняются
+	holidays     openinghours.Calendar  // праздники для правил PH в opening_hours
+	schedules    schedules
+	datasets     terminal.DatasetStore // nil - набор банкоматов не сохраняется между рестартами
+	terminals    TerminalIndex         // nil - наши банкоматы никуда не передаются (Postgres читает terminals сам)
+	// demo - можно отдавать сгенерированные данные (случайных конкурентов, условную
+	// тепловую карту); вне демо-режима они в ответ не попадают
+	demo bool
+
+	// Кэши для скорости, отдельно по каждому городу
+	mu    sync.RWMutex
+	cache map[string]cityATMs
+
+	// lastGood - последний успешный ответ каждого источника по городам (город -> Source.Key);
+	// подставляется, если источник не ответил. Трогает только горутина обновления
+	// (и Restore до ее запуска).
+	lastGood map[string]map[string]terminal.SourceResponse
+	// sourceSchedules - расписание опроса каждого источника по городам (город -> Source.Key);
+	// трогает только горутина обновления
+	sourceSchedules map[string]map[string]*sourceSchedule
+}
 
-	// Кэши для скорости
-	forteCache []terminal.ATM
-	compCache  []terminal.ATM
+// cityATMs - банкоматы города после последнего обновления источников
+type cityATMs struct {
+	forte       []terminal.ATM
+	competitors []terminal.ATM
+	updatedAt   time.Time
 }
 
-func NewService(repo terminal.Repository, osm *provider.OSMProvider, grid *analytics.GridService) *Service {
-	s := &Service{repo: repo, osm: osm, grid: grid}
-	go s.refreshData() // Запускаем обновление при старте
+func NewService(repo terminal.Repository, sources []Source, grid *analytics.GridService, zones traffic.ZoneRepository,
+	cities *city.Registry, bank config.BankConfig, mergeRadiusM float64, snapshots terminal.SnapshotStore,
+	holidays openinghours.Calendar, datasets terminal.DatasetStore, terminals TerminalIndex, demo bool) *Service {
+	s := &Service{
+		repo: repo, sources: sources, grid: grid, zones: zones, cities: cities, bank: bank,
+		mergeRadiusM: mergeRadiusM, snapshots: snapshots, holidays: holidays, datasets: datasets,
+		terminals: terminals, demo: demo,
+		cache: make(map[string]cityATMs), lastGood: make(map[string]map[string]terminal.SourceResponse),
+		sourceSchedules: make(map[string]map[string]*sourceSchedule),
+	}
 	return s
 }
 
-func (s *Service) refreshData() {
-	fmt.Println("🔄 Updating ATM data from OpenStreetMap...")
+// refreshData опрашивает источники, которым пора обновиться, во всех городах
+// и возвращает время следующего опроса
+func (s *Service) refreshData(cfg config.RefreshConfig) time.Time {
+	now := time.Now()
+	next := now.Add(cfg.Interval)
+	for _, c := range s.cities.All() {
+		if at := s.refreshCity(c, now, cfg); at.Before(next) {
+			next = at
+		}
+	}
+	return next
+}
 
-	// 1. Получаем ВСЕ банкоматы города
-	allATMs, err := s.osm.FetchAllATMs()
-	if err != nil {
-		fmt.Println("❌ OSM Error:", err)
-		return
+// refreshCity опрашивает источники города, которым пора обновиться (см. sourceSchedule),
+// и пересобирает банкоматы, если хоть один ответил. Вместо не ответившего или еще
+// не подошедшего по расписанию источника берется его прошлый успешный ответ.
+// Возвращает время ближайшего следующего опроса источников города.
+func (s *Service) refreshCity(c city.City, now time.Time, cfg config.RefreshConfig) time.Time {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// 1. Опрашиваем источники, которым пора, и собираем банкоматы из всех по приоритету
+	lastGood := s.lastGood[c.ID]
+	if lastGood == nil {
+		lastGood = make(map[string]terminal.SourceResponse)
+		s.lastGood[c.ID] = lastGood
+	}
+	next := now.Add(cfg.Interval)
+	fetched := false
+	for _, src := range s.sources {
+		sched := s.schedule(c.ID, src.Key)
+		if sched.due(now) {
+			fmt.Printf("🔄 Updating ATM data from %s (%s)...\n", src.Key, c.Name)
+			atms, err := src.FetchATMs(ctx, c)
+			if err != nil {
+				// Остальные источники все равно используем, повторяем только этот
+				wait := sched.failed(now, cfg)
+				fmt.Printf("❌ Source Error (%s): %v; повтор через %s\n", c.Name, err, wait.Round(time.Second))
+			} else {
+				sched.succeeded(now, cfg)
+				lastGood[src.Key] = terminal.SourceResponse{FetchedAt: now, ATMs: atms}
+				s.saveSnapshot(ctx, c, src.Name(), atms)
+				fetched = true
+			}
+		}
+		if sched.next.Before(next) {
+			next = sched.next
+		}
+	}
+	if !fetched {
+		return next // новых ответов нет - прошлые данные остаются как есть
+	}
+
+	m := merger{bank: s.bank, radiusM: s.mergeRadiusM}
+	asOf := now // данные не новее самого старого из использованных ответов
+	for _, src := range s.sources {
+		resp, ok := lastGood[src.Key]
+		if !ok {
+			continue
+		}
+		if resp.FetchedAt.Before(asOf) {
+			asOf = resp.FetchedAt
+		}
+		fmt.Printf("📍 %s (%s): ответ от %s, %d банкоматов, добавлено %d\n", src.Key, c.Name,
+			resp.FetchedAt.In(c.Location).Format("02.01 15:04"), len(resp.ATMs), m.add(src, resp.ATMs, resp.FetchedAt))
+	}
+
+	// 2. В источниках нет данных о кассетах наших банкоматов.
+	// Берем их из репозитория (БД или Mock в демо-режиме)
+	for i := range m.forte {
+		atm := &m.forte[i]
+		if err := s.repo.EnrichATM(ctx, atm); err != nil && !errors.Is(err, terminal.ErrNotFound) {
+			fmt.Printf("⚠️ Не удалось обогатить банкомат %s: %v\n", atm.ID, err)
+		}
+		atm.Provenance.Resolve()
 	}
+	// Потоки конкурентов - только оценка (Provenance.Metrics: estimated)
+	for i := range m.competitors {
+		atm := &m.competitors[i]
+		if err := s.repo.EnrichCompetitor(ctx, atm); err != nil {
+			fmt.Printf("⚠️ Не удалось оценить потоки банкомата %s: %v\n", atm.ID, err)
+		}
+		atm.Provenance.Resolve()
+	}
+
+	data := cityATMs{forte: m.forte, competitors: m.competitors, updatedAt: asOf}
+	s.mu.Lock()
+	s.cache[c.ID] = data
+	s.mu.Unlock()
+	s.publishTerminals(c, data.forte)
+	fmt.Printf("✅ Data Updated (%s): %d Forte ATMs, %d Competitors\n", c.Name, len(m.forte), len(m.competitors))
+	s.saveDataset(ctx, c, data)
+	return next
+}
 
-	var forte []terminal.ATM
-	var others []terminal.ATM
-
-	// 2. Сортируем: Forte vs Остальные
-	for i := range allATMs {
-		atm := allATMs[i]
-
-		// Проверка: это Forte?
-		name := strings.ToLower(atm.Bank) + strings.ToLower(atm.Name)
-		if strings.Contains(name, "forte") {
-			// Это наш банкомат! Но в OSM нет данных о кассетах.
-			// Генерируем их через MockRepo
-			s.repo.EnrichATM(&atm)
-			forte = append(forte, atm)
-		} else {
-			// Это конкурент
-			atm.IsForte = false
-			others = append(others, atm)
+// publishTerminals передает наши банкоматы города в TerminalIndex. Банкомат не из
+// справочника (без terminal_id) передается под своим id: закрытым он не считается,
+// но зону занимает.
+func (s *Service) publishTerminals(c city.City, forte []terminal.ATM) {
+	if s.terminals == nil {
+		return
+	}
+	points := make(map[string]geo.Point, len(forte))
+	for _, atm := range forte {
+		id := atm.TerminalID
+		if id == "" {
+			id = atm.ID
 		}
+		points[id] = geo.Point{Lat: atm.Lat, Lng: atm.Lng}
 	}
+	s.terminals.SetTerminals(c.ID, points)
+}
 
-	s.forteCache = forte
-	s.compCache = others
-	fmt.Printf("✅ Data Updated: %d Forte ATMs, %d Competitors\n", len(forte), len(others))
+// saveSnapshot сохраняет ответ источника как есть (до слияния) и печатает изменения сети
+func (s *Service) saveSnapshot(ctx context.Context, c city.City, source string, atms []terminal.ATM) {
+	if s.snapshots == nil {
+		return
+	}
+	stats, err := s.snapshots.SaveSnapshot(ctx, c.ID, source, atms)
+	switch {
+	case err != nil:
+		fmt.Printf("⚠️ Не удалось сохранить снимок %s (%s): %v\n", source, c.Name, err)
+	case stats.ATMs == 0:
+		// у источника нет OSM id (справочник, обход) - сравнивать нечего
+	case stats.First:
+		fmt.Printf("📸 Первый снимок %s (%s): %d банкоматов\n", source, c.Name, stats.ATMs)
+	case stats.Added+stats.Removed+stats.Moved > 0:
+		fmt.Printf("📸 Изменения сети %s (%s): +%d, -%d, перенесено %d\n", source, c.Name, stats.Added, stats.Removed, stats.Moved)
+	}
 }
 
-func (s *Service) GetDashboardData() DashboardResponse {
-	// Если кэш пуст (OSM еще не ответил), генерируем фейки
-	competitors := s.compCache
-	if len(competitors) == 0 {
-		competitors = s.repo.GenerateRandomCompetitors(300)
+// GetDashboardData собирает данные города для карты; тепловая карта строится
+// по трафику зон в заданном срезе времени, банкоматы отбираются фильтром,
+// а если задан момент at (не нулевой) - только работающие в этот момент
+func (s *Service) GetDashboardData(ctx context.Context, c city.City, slice traffic.TimeSlice, filter terminal.Filter, at time.Time) DashboardResponse {
+	s.mu.RLock()
+	data := s.cache[c.ID]
+	s.mu.RUnlock()
+
+	// Если кэш пуст (источники еще не ответили), в демо-режиме генерируем фейки
+	competitors := data.competitors
+	if len(competitors) == 0 && s.demo {
+		competitors = s.repo.GenerateRandomCompetitors(c, 300)
 	}
 
 	// Forte тоже берем из кэша (если там пусто, можно вернуть старый хардкод, но OSM обычно находит)
-	forte := s.forteCache
+	forte := data.forte
+
+	heatmap, heatmapKind := s.heatmap(ctx, c, slice)
+	resp := DashboardResponse{
+		City:        c,
+		DataAsOf:    dataAsOf(data.updatedAt, c),
+		DataAgeSec:  dataAge(data.updatedAt),
+		Forte:       s.allowed(filter.Apply(forte)),
+		Competitors: s.allowed(filter.Apply(competitors)),
+		HeatmapGrid: heatmap,
+		TimeSlice:   slice,
+		Filter:      filter,
+	}
+	if !at.IsZero() {
+		at = at.In(c.Location)
+		resp.Availability = &Availability{At: at}
+		resp.Forte = s.openAt(resp.Forte, at, resp.Availability)
+		resp.Competitors = s.openAt(resp.Competitors, at, resp.Availability)
+	}
+	resp.Provenance = provenance(heatmapKind, resp.Forte, resp.Competitors)
+	return resp
+}
 
-	return DashboardResponse{
-		Forte:       forte,
-		Competitors: competitors,
-		HeatmapGrid: s.grid.GenerateHexGrid(),
+// heatmap - зоны трафика города и происхождение карты. Пока трафик не загружен,
+// в демо-режиме - условная сетка (synthetic), иначе пустая карта (HeatmapNone).
+func (s *Service) heatmap(ctx context.Context, c city.City, slice traffic.TimeSlice) (analytics.GeoJSONFeatureCollection, string) {
+	if s.zones != nil {
+		zones, err := s.zones.ZonesTraffic(ctx, c.Bounds(), slice)
+		if err != nil {
+			fmt.Println("⚠️ Не удалось получить трафик зон:", err)
+		}
+		if len(zones) > 0 {
+			return s.grid.ZoneHeatmap(zones), terminal.ProvenanceReal
+		}
+	}
+	if !s.demo {
+		return analytics.GeoJSONFeatureCollection{Type: "FeatureCollection", Features: []analytics.GeoJSONFeature{}}, HeatmapNone
 	}
+	return s.grid.GenerateHexGrid(c), terminal.ProvenanceSynthetic
 }
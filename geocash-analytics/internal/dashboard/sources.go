@@ -0,0 +1,117 @@
+package dashboard
+
+import (
+	"strings"
+	"time"
+
+	"geocash/internal/config"
+	"geocash/internal/domain/terminal"
+	"geocash/internal/geo"
+)
+
+// Source - источник банкоматов с ролью (config.ATMRole*): какие банкоматы из него брать
+type Source struct {
+	terminal.Source
+	Role string
+	// Key - уникальный ключ источника (config.ATMSourceConfig.Key): по нему ведутся
+	// расписание опроса и последние ответы; имя у нескольких survey одинаковое
+	Key string
+}
+
+// merger сводит банкоматы из источников по приоритету: банкомат отбрасывается,
+// если источник выше уже дал банкомат той же стороны (наш/конкурент) и того же
+// банка ближе radiusM. У оставшегося дубля перенимаются OSM id, terminal_id и атрибуты
+// из тегов OSM, если их не было (в справочнике терминалов нет режима работы и валют).
+type merger struct {
+	bank    config.BankConfig
+	radiusM float64
+
+	forte       []terminal.ATM
+	competitors []terminal.ATM
+}
+
+// isOwn - наш банкомат: из справочника терминалов или бренд опознан как bank.id
+func (m *merger) isOwn(atm terminal.ATM) bool {
+	return atm.IsForte || atm.BankID == m.bank.ID
+}
+
+// add добавляет банкоматы очередного источника (источники - по убыванию приоритета),
+// полученные в момент fetchedAt
+func (m *merger) add(src Source, atms []terminal.ATM, fetchedAt time.Time) (added int) {
+	for _, atm := range atms {
+		atm.Source = src.Name()
+		atm.Provenance = terminal.Provenance{Location: terminal.ProvenanceReal, AsOf: fetchedAt}
+		atm.Provenance.Resolve()
+		own := m.isOwn(atm)
+		switch {
+		case own && src.Role == config.ATMRoleCompetitors, !own && src.Role == config.ATMRoleOwn:
+			continue
+		}
+
+		list := &m.competitors
+		if own {
+			atm.IsForte = true
+			list = &m.forte
+		}
+		if m.mergeDuplicate(*list, atm, !own) {
+			continue
+		}
+		*list = append(*list, atm)
+		added++
+	}
+	return added
+}
+
+// mergeDuplicate ищет в list тот же банкомат; сравнение банка нужно только для конкурентов
+func (m *merger) mergeDuplicate(list []terminal.ATM, atm terminal.ATM, sameBank bool) bool {
+	p := geo.Point{Lat: atm.Lat, Lng: atm.Lng}
+	for i := range list {
+		kept := &list[i]
+		if sameBank && !isSameBank(*kept, atm) {
+			continue
+		}
+		if geo.Distance(p, geo.Point{Lat: kept.Lat, Lng: kept.Lng}) > m.radiusM {
+			continue
+		}
+		if kept.OSMID == "" {
+			kept.OSMID = atm.OSMID
+		}
+		// terminal_id из справочника точнее сопоставления по координатам в EnrichATM
+		if kept.TerminalID == "" {
+			kept.TerminalID = atm.TerminalID
+		}
+		fillAttributes(kept, atm)
+		return true
+	}
+	return false
+}
+
+// isSameBank - по id справочника, а для неопознанных - по названию
+func isSameBank(a, b terminal.ATM) bool {
+	if a.BankID != "" || b.BankID != "" {
+		return a.BankID == b.BankID
+	}
+	return strings.EqualFold(a.Bank, b.Bank)
+}
+
+// fillAttributes дополняет незаполненные атрибуты kept значениями из дубля
+func fillAttributes(kept *terminal.ATM, dup terminal.ATM) {
+	if kept.OpeningHours == "" {
+		kept.OpeningHours, kept.Open24x7 = dup.OpeningHours, dup.Open24x7
+	}
+	if kept.CashIn == nil {
+		kept.CashIn = dup.CashIn
+	}
+	if len(kept.Currencies) == 0 {
+		kept.Currencies = dup.Currencies
+	}
+	if kept.Wheelchair == "" {
+		kept.Wheelchair = dup.Wheelchair
+	}
+	if kept.Indoor == nil {
+		kept.Indoor = dup.Indoor
+	}
+	if kept.Level == "" {
+		kept.Level = dup.Level
+	}
+}
@@ -0,0 +1,262 @@
+// Package brand - справочник банков: приводит бренды из OSM и файлов обхода
+// ("Халык Банк", "Halyk Bank", "Народный банк") к одному id банка
+package brand
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"geocash/internal/config"
+)
+
+// Способы, которыми найден банк
+const (
+	MethodWikidata = "wikidata" // тег brand:wikidata / operator:wikidata
+	MethodAlias    = "alias"    // название совпало с написанием из справочника
+	MethodToken    = "token"    // написание входит в название ("Halyk Bank отделение №5")
+	MethodFuzzy    = "fuzzy"    // с опечатками в пределах fuzzy_max_distance
+)
+
+// Match - найденный банк
+type Match struct {
+	BankID string `json:"bankId"`
+	Name   string `json:"name"`
+	Method string `json:"method"`
+}
+
+// maxUnmatched - сколько несопоставленных названий помнить: каждая новая опечатка
+// в OSM или обходе добавляет запись, поэтому давно не встречавшиеся вытесняются
+const maxUnmatched = 1000
+
+// Unmatched - название, которое не удалось сопоставить (для пополнения справочника)
+type Unmatched struct {
+	Name      string    `json:"name"`
+	Count     int       `json:"count"` // сколько раз встретилось с момента запуска
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+type alias struct {
+	tokens []string
+	key    string
+	bank   int
+}
+
+// Dictionary - справочник банков; безопасен для параллельного использования
+type Dictionary struct {
+	banks    []config.BrandConfig
+	wikidata map[string]int // Q... -> индекс банка
+	exact    map[string]int // нормализованное написание -> индекс банка
+	aliases  []alias        // для поиска вхождений и опечаток, длинные первыми
+	maxDist  float64
+
+	mu        sync.Mutex
+	unmatched map[string]*Unmatched
+}
+
+// NewDictionary строит справочник; id и название банка тоже считаются написаниями
+func NewDictionary(cfg config.BrandsConfig) *Dictionary {
+	d := &Dictionary{
+		banks:     cfg.Banks,
+		wikidata:  make(map[string]int),
+		exact:     make(map[string]int),
+		maxDist:   cfg.FuzzyMaxDistance,
+		unmatched: make(map[string]*Unmatched),
+	}
+	for i, b := range cfg.Banks {
+		for _, q := range b.Wikidata {
+			d.wikidata[strings.ToUpper(strings.TrimSpace(q))] = i
+		}
+		for _, name := range append([]string{b.ID, b.Name}, b.Aliases...) {
+			tokens := normalize(name)
+			if len(tokens) == 0 {
+				continue
+			}
+			key := strings.Join(tokens, " ")
+			if _, dup := d.exact[key]; dup {
+				continue
+			}
+			d.exact[key] = i
+			d.aliases = append(d.aliases, alias{tokens: tokens, key: key, bank: i})
+		}
+	}
+	sort.SliceStable(d.aliases, func(i, j int) bool { return len(d.aliases[i].key) > len(d.aliases[j].key) })
+	return d
+}
+
+// MatchTags ищет банк по тегам OSM: сначала brand:wikidata, затем brand, operator, name.
+// Несопоставленное название запоминается для Unmatched.
+func (d *Dictionary) MatchTags(tags map[string]string) (Match, bool) {
+	for _, key := range []string{"brand:wikidata", "operator:wikidata"} {
+		for _, q := range strings.Split(tags[key], ";") {
+			if i, ok := d.wikidata[strings.ToUpper(strings.TrimSpace(q))]; ok {
+				return d.match(i, MethodWikidata), true
+			}
+		}
+	}
+	return d.MatchName(tags["brand"], tags["operator"], tags["name"])
+}
+
+// MatchName ищет банк по названиям в порядке приоритета (пустые пропускаются).
+// Первое непустое название без совпадения запоминается для Unmatched.
+func (d *Dictionary) MatchName(names ...string) (Match, bool) {
+	var parsed [][]string
+	for _, name := range names {
+		if tokens := normalize(name); len(tokens) > 0 {
+			parsed = append(parsed, tokens)
+		}
+	}
+
+	// Чем точнее способ, тем раньше: точное совпадение в любом из полей
+	// надежнее вхождения в первое
+	for _, tokens := range parsed {
+		if i, ok := d.exact[strings.Join(tokens, " ")]; ok {
+			return d.match(i, MethodAlias), true
+		}
+	}
+	for _, tokens := range parsed {
+		if i, ok := d.contains(tokens); ok {
+			return d.match(i, MethodToken), true
+		}
+	}
+	for _, tokens := range parsed {
+		if i, ok := d.fuzzy(tokens); ok {
+			return d.match(i, MethodFuzzy), true
+		}
+	}
+
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			d.remember(name)
+			break
+		}
+	}
+	return Match{}, false
+}
+
+// Bank - банк по id
+func (d *Dictionary) Bank(id string) (config.BrandConfig, bool) {
+	for _, b := range d.banks {
+		if b.ID == id {
+			return b, true
+		}
+	}
+	return config.BrandConfig{}, false
+}
+
+// Unmatched - несопоставленные названия, частые первыми
+func (d *Dictionary) Unmatched() []Unmatched {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]Unmatched, 0, len(d.unmatched))
+	for _, u := range d.unmatched {
+		out = append(out, *u)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func (d *Dictionary) match(i int, method string) Match {
+	return Match{BankID: d.banks[i].ID, Name: d.banks[i].Name, Method: method}
+}
+
+// contains - написание из справочника целыми словами внутри названия
+func (d *Dictionary) contains(tokens []string) (int, bool) {
+	for _, a := range d.aliases {
+		for start := 0; start+len(a.tokens) <= len(tokens); start++ {
+			if equalTokens(tokens[start:start+len(a.tokens)], a.tokens) {
+				return a.bank, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// fuzzy - ближайшее написание по Левенштейну среди окон названия той же длины в словах
+func (d *Dictionary) fuzzy(tokens []string) (int, bool) {
+	if d.maxDist == 0 {
+		return 0, false
+	}
+	best, bestDist := -1, 0
+	for _, a := range d.aliases {
+		limit := int(d.maxDist * float64(len([]rune(a.key))))
+		if limit == 0 {
+			continue // короткие написания ("bcc", "втб") только точно
+		}
+		for start := 0; start+len(a.tokens) <= len(tokens); start++ {
+			dist := levenshtein(strings.Join(tokens[start:start+len(a.tokens)], " "), a.key)
+			if dist <= limit && (best < 0 || dist < bestDist) {
+				best, bestDist = a.bank, dist
+			}
+		}
+	}
+	return best, best >= 0
+}
+
+func (d *Dictionary) remember(name string) {
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	u, ok := d.unmatched[name]
+	if !ok {
+		if len(d.unmatched) >= maxUnmatched {
+			d.evictOldest()
+		}
+		u = &Unmatched{Name: name, FirstSeen: now}
+		d.unmatched[name] = u
+	}
+	u.Count++
+	u.LastSeen = now
+}
+
+// evictOldest забывает название, которое дольше всех не встречалось
+func (d *Dictionary) evictOldest() {
+	var oldest *Unmatched
+	for _, u := range d.unmatched {
+		if oldest == nil || u.LastSeen.Before(oldest.LastSeen) ||
+			(u.LastSeen.Equal(oldest.LastSeen) && u.Count < oldest.Count) {
+			oldest = u
+		}
+	}
+	if oldest != nil {
+		delete(d.unmatched, oldest.Name)
+	}
+}
+
+func equalTokens(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// levenshtein - расстояние редактирования по символам
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
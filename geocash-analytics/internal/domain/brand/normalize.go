@@ -0,0 +1,48 @@
+package brand
+
+import (
+	"strings"
+	"unicode"
+)
+
+// translit - кириллица (русская и казахская) в латиницу, чтобы "Форте" и "Forte",
+// "Каспи" и "Kaspi" совпадали без отдельных написаний
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'ә': "a", 'ғ': "g", 'қ': "k", 'ң': "n", 'ө': "o", 'ұ': "u", 'ү': "u",
+	'һ': "h", 'і': "i",
+}
+
+// stopWords - слова, которые не отличают один банк от другого (после транслитерации)
+var stopWords = map[string]bool{
+	"bank": true, "banka": true, "banke": true, "banki": true, "banku": true,
+	"ao": true, "at": true, "jsc": true, "ooo": true, "too": true, "dbao": true,
+	"atm": true, "bankomat": true, "kz": true,
+	"kazakhstan": true, "kazahstan": true, "qazaqstan": true, "of": true,
+}
+
+// normalize - слова названия: нижний регистр, латиница, без знаков и служебных слов
+func normalize(s string) []string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case translit[r] != "" || r == 'ъ' || r == 'ь':
+			b.WriteString(translit[r])
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteByte(' ')
+		}
+	}
+
+	var tokens []string
+	for _, t := range strings.Fields(b.String()) {
+		if !stopWords[t] {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,91 @@
+// Package city - справочник городов: границы, центр и часовой пояс.
+// Общий для OSM-провайдера, сетки тепловой карты, импорта трафика и mock-данных.
+package city
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"geocash/internal/config"
+	"geocash/internal/geo"
+)
+
+// ErrUnknownCity - города нет в справочнике
+var ErrUnknownCity = errors.New("неизвестный город")
+
+// City - город из config.cities
+type City struct {
+	ID       string         `json:"id"`
+	Name     string         `json:"name"`
+	BBox     config.BBox    `json:"bbox"`
+	Center   config.LatLng  `json:"center"`
+	Timezone string         `json:"timezone"`
+	Location *time.Location `json:"-"`
+}
+
+// Bounds - bbox города в виде geo.Bounds
+func (c City) Bounds() geo.Bounds {
+	return geo.Bounds{MinLng: c.BBox.MinLng, MinLat: c.BBox.MinLat, MaxLng: c.BBox.MaxLng, MaxLat: c.BBox.MaxLat}
+}
+
+// Registry - все города сервиса в порядке из конфига
+type Registry struct {
+	cities []City
+	byID   map[string]City
+	def    string
+}
+
+// NewRegistry строит справочник из проверенного конфига
+func NewRegistry(cfg config.Config) (*Registry, error) {
+	r := &Registry{byID: make(map[string]City), def: cfg.DefaultCity}
+	for _, c := range cfg.Cities {
+		loc, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("город %s: %w", c.ID, err)
+		}
+		city := City{ID: c.ID, Name: c.Name, BBox: c.BBox, Center: c.Center, Timezone: c.Timezone, Location: loc}
+		r.cities = append(r.cities, city)
+		r.byID[c.ID] = city
+	}
+	if _, ok := r.byID[r.def]; !ok {
+		return nil, fmt.Errorf("%w: default_city %q", ErrUnknownCity, r.def)
+	}
+	return r, nil
+}
+
+// All - все города
+func (r *Registry) All() []City {
+	return r.cities
+}
+
+// Default - город по умолчанию
+func (r *Registry) Default() City {
+	return r.byID[r.def]
+}
+
+// Get - город по id
+func (r *Registry) Get(id string) (City, error) {
+	c, ok := r.byID[id]
+	if !ok {
+		return City{}, fmt.Errorf("%w %q (доступно: %s)", ErrUnknownCity, id, strings.Join(r.ids(), ", "))
+	}
+	return c, nil
+}
+
+// Resolve - город из параметра запроса; пустой id - город по умолчанию
+func (r *Registry) Resolve(id string) (City, error) {
+	if id == "" {
+		return r.Default(), nil
+	}
+	return r.Get(strings.ToLower(id))
+}
+
+func (r *Registry) ids() []string {
+	ids := make([]string, len(r.cities))
+	for i, c := range r.cities {
+		ids[i] = c.ID
+	}
+	return ids
+}
@@ -0,0 +1,58 @@
+package terminal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DatasetFormat - версия формата Dataset; меняется при несовместимых изменениях ATM
+const DatasetFormat = 1
+
+// ErrDatasetFormat - сохраненный набор другой версии формата, загружать нельзя
+var ErrDatasetFormat = errors.New("неподдерживаемый формат набора банкоматов")
+
+// Dataset - банкоматы города после последнего удачного обновления источников
+type Dataset struct {
+	Format      int       `json:"format"` // DatasetFormat
+	CityID      string    `json:"cityId"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Forte       []ATM     `json:"forte"`
+	Competitors []ATM     `json:"competitors"`
+	// Sources - последние удачные ответы источников по ключу (тип или тип:путь): подставляются
+	// вместо источника, который не ответил (в том числе сразу после рестарта)
+	Sources map[string]SourceResponse `json:"sources"`
+}
+
+// SourceResponse - удачный ответ источника
+type SourceResponse struct {
+	FetchedAt time.Time `json:"fetchedAt"`
+	ATMs      []ATM     `json:"atms"`
+}
+
+// DecodeDataset разбирает сохраненный набор, сначала сверяя версию формата:
+// набор другой версии не разбирается дальше (поля могли поменять тип)
+func DecodeDataset(data []byte) (Dataset, error) {
+	var d Dataset
+	var header struct {
+		Format int `json:"format"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return d, err
+	}
+	if header.Format != DatasetFormat {
+		return d, fmt.Errorf("%w: %d, ожидается %d", ErrDatasetFormat, header.Format, DatasetFormat)
+	}
+	err := json.Unmarshal(data, &d)
+	return d, err
+}
+
+// DatasetStore хранит последний удачный набор банкоматов каждого города,
+// чтобы после рестарта карта не была пустой, пока источники не ответят
+type DatasetStore interface {
+	SaveDataset(ctx context.Context, d Dataset) error
+	// LoadDataset - сохраненный набор города; ErrNotFound - еще не сохранялся
+	LoadDataset(ctx context.Context, cityID string) (Dataset, error)
+}
@@ -0,0 +1,62 @@
+package terminal
+
+// Типы кассет
+const (
+	CassetteCashOut = "Cash-Out"
+	CassetteCashIn  = "Cash-In"
+)
+
+// Статусы кассет (строки уже используются фронтендом)
+const (
+	CassetteStatusOK    = "OK"
+	CassetteStatusLow   = "Low (Мало денег)"
+	CassetteStatusEmpty = "Empty (Пусто)"
+	CassetteStatusFull  = "Full (Переполнен)"
+)
+
+// Статусы эффективности терминала
+const (
+	EfficiencyEffective   = "Effective"
+	EfficiencyIneffective = "Ineffective"
+	EfficiencyNormal      = "Normal"
+)
+
+// CassetteStatus вычисляет статус кассеты по ее заполненности:
+// выдача "Low" ниже 10% емкости, прием "Full" выше 90%.
+func CassetteStatus(cassetteType string, amount, capacity float64) string {
+	switch cassetteType {
+	case CassetteCashOut:
+		if amount <= 0 {
+			return CassetteStatusEmpty
+		}
+		if amount < capacity*0.1 {
+			return CassetteStatusLow
+		}
+	case CassetteCashIn:
+		if capacity > 0 && amount > capacity*0.9 {
+			return CassetteStatusFull
+		}
+	}
+	return CassetteStatusOK
+}
+
+// EvaluateEfficiency рассчитывает статус эффективности (Effective / Ineffective / Normal)
+func EvaluateEfficiency(atm *ATM) string {
+	for _, c := range atm.Cassettes {
+		// Если нет денег или переполнен
+		if c.Status == CassetteStatusEmpty || c.Status == CassetteStatusFull {
+			return EfficiencyIneffective
+		}
+	}
+
+	switch {
+	case atm.DowntimePct > 0.10:
+		// Часто ломается
+		return EfficiencyIneffective
+	case atm.WithdrawalFreqPerDay > 300 && atm.DowntimePct < 0.03:
+		// Много транзакций и редко ломается
+		return EfficiencyEffective
+	default:
+		return EfficiencyNormal
+	}
+}
@@ -20,22 +20,46 @@ type Cassette struct {
 	Status   string  `json:"status"`   // "OK", "Low", "Full"
 }
 
+// Доступность для маломобильных (тег wheelchair)
+const (
+	AccessYes     = "yes"
+	AccessLimited = "limited"
+	AccessNo      = "no"
+)
+
 // ATM - основная сущность терминала
 type ATM struct {
 	// --- Базовые поля ---
-	ID       int     `json:"id"`
+	ID       string  `json:"id"` // уникален в наборе: "<источник>:<id в источнике>", например "osm:node/123"
 	Name     string  `json:"name"`
 	Lat      float64 `json:"lat"`
 	Lng      float64 `json:"lng"`
 	IsForte  bool    `json:"isForte"`
 	District string  `json:"district"`
 	Bank     string  `json:"bank,omitempty"`
+	BankID   string  `json:"bankId,omitempty"` // id из справочника brands; пусто - банк не опознан
+	OSMID    string  `json:"osmId,omitempty"`  // "node/123", "way/456"
+	Source   string  `json:"source"`           // откуда взят: osm, registry, survey, mock
+	// Provenance - реальные, оценочные или сгенерированные данные
+	Provenance Provenance `json:"provenance"`
+
+	// --- Атрибуты из тегов OSM (nil/пусто - тег не заполнен) ---
+	OpeningHours string   `json:"openingHours,omitempty"` // opening_hours как есть: "24/7", "Mo-Sa 10:00-22:00"
+	Open24x7     *bool    `json:"open24x7,omitempty"`
+	CashIn       *bool    `json:"cashIn,omitempty"`     // cash_in: принимает наличные
+	Currencies   []string `json:"currencies,omitempty"` // currency:XXX=yes, например ["KZT", "USD"]
+	Wheelchair   string   `json:"wheelchair,omitempty"` // Access*
+	Indoor       *bool    `json:"indoor,omitempty"`     // внутри здания (ТЦ, отделение)
+	Level        string   `json:"level,omitempty"`      // этаж: "0", "-1", "1;2"
+	// Availability - в запросе с моментом времени (at): open или unknown
+	Availability string `json:"availability,omitempty"`
 
-	// --- Поля для Конкурентов (Оценочные данные) ---
+	// --- Поля для Конкурентов (Оценочные данные, Provenance.Metrics: estimated) ---
 	EstWithdrawalKZT float64 `json:"estWithdrawalKZT,omitempty"` // Оценка: Снятие
 	EstDepositKZT    float64 `json:"estDepositKZT,omitempty"`    // Оценка: Внесение
 
 	// --- Поля для Forte (Детальные данные) ---
+	TerminalID        string  `json:"terminalId,omitempty"` // ID из справочника terminals
 	AvgCashBalanceKZT float64 `json:"avgCashBalanceKZT,omitempty"`
 	TotalCashKZT      float64 `json:"totalCashKZT,omitempty"`
 
@@ -47,10 +71,30 @@ type ATM struct {
 	Complaints []Complaint `json:"complaints,omitempty"`
 }
 
-// CashBalance - сущность для истории баланса (Нужна для исправления ошибки в repository.go)
+// CashBalance - замер остатка наличности (таблица terminal_loadings)
 type CashBalance struct {
-	TerminalID     int
-	RecordTime     time.Time
-	CurrentBalance int
-	MaxCapacity    int
+	TerminalID     string    `json:"terminalId"`
+	RecordTime     time.Time `json:"recordTime"`
+	CurrentBalance float64   `json:"currentBalance"`
+	MaxCapacity    float64   `json:"maxCapacity"`
+}
+
+// Transaction - операция по терминалу (таблица terminal_transactions)
+type Transaction struct {
+	TransactionID string
+	TerminalID    string
+	Time          time.Time
+	OperationType string // WITHDRAWAL / DEPOSIT
+	Amount        float64
+	Currency      string
+	Status        string // COMPLETED / FAILED / REVERSED
+}
+
+// ServiceRecord - запись об обслуживании (таблица terminal_service_history)
+type ServiceRecord struct {
+	TerminalID  string
+	ServiceDate time.Time
+	ServiceType string
+	IsCritical  bool
+	Description string
 }
@@ -0,0 +1,111 @@
+package terminal
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Filter - отбор банкоматов по атрибутам; пустое поле - не фильтровать.
+// Банкомат, у которого атрибут неизвестен, под фильтр по этому атрибуту не подходит.
+type Filter struct {
+	Open24x7   *bool    `json:"open24x7,omitempty"`
+	CashIn     *bool    `json:"cashIn,omitempty"`
+	Currencies []string `json:"currencies,omitempty"` // нужны все перечисленные
+	Wheelchair string   `json:"wheelchair,omitempty"`
+	Indoor     *bool    `json:"indoor,omitempty"`
+	Level      string   `json:"level,omitempty"`
+}
+
+// ParseFilter разбирает параметры запроса: open24x7, cash_in, indoor = true|false,
+// currency = USD,EUR, wheelchair = yes|limited|no, level = 0
+func ParseFilter(q url.Values) (Filter, error) {
+	var f Filter
+	var err error
+	if f.Open24x7, err = parseBool(q, "open24x7"); err != nil {
+		return Filter{}, err
+	}
+	if f.CashIn, err = parseBool(q, "cash_in"); err != nil {
+		return Filter{}, err
+	}
+	if f.Indoor, err = parseBool(q, "indoor"); err != nil {
+		return Filter{}, err
+	}
+
+	if v := q.Get("currency"); v != "" {
+		for _, code := range strings.Split(v, ",") {
+			code = strings.ToUpper(strings.TrimSpace(code))
+			if len(code) != 3 {
+				return Filter{}, fmt.Errorf("параметр currency: ожидаются коды валют через запятую (USD,EUR), получено %q", v)
+			}
+			f.Currencies = append(f.Currencies, code)
+		}
+	}
+
+	if v := q.Get("wheelchair"); v != "" {
+		f.Wheelchair = strings.ToLower(v)
+		switch f.Wheelchair {
+		case AccessYes, AccessLimited, AccessNo:
+		default:
+			return Filter{}, fmt.Errorf("параметр wheelchair: допустимо %s, %s, %s, получено %q", AccessYes, AccessLimited, AccessNo, v)
+		}
+	}
+	f.Level = strings.TrimSpace(q.Get("level"))
+	return f, nil
+}
+
+func parseBool(q url.Values, key string) (*bool, error) {
+	v := q.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, fmt.Errorf("параметр %s: допустимо true или false, получено %q", key, v)
+	}
+	return &b, nil
+}
+
+// IsZero - фильтр ничего не отбирает
+func (f Filter) IsZero() bool {
+	return f.Open24x7 == nil && f.CashIn == nil && f.Indoor == nil &&
+		len(f.Currencies) == 0 && f.Wheelchair == "" && f.Level == ""
+}
+
+// Match - подходит ли банкомат под фильтр
+func (f Filter) Match(atm ATM) bool {
+	switch {
+	case !matchBool(f.Open24x7, atm.Open24x7),
+		!matchBool(f.CashIn, atm.CashIn),
+		!matchBool(f.Indoor, atm.Indoor),
+		f.Wheelchair != "" && f.Wheelchair != atm.Wheelchair,
+		f.Level != "" && !slices.Contains(strings.Split(atm.Level, ";"), f.Level):
+		return false
+	}
+	for _, code := range f.Currencies {
+		if !slices.Contains(atm.Currencies, code) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchBool(want, got *bool) bool {
+	return want == nil || got != nil && *got == *want
+}
+
+// Apply - банкоматы, подходящие под фильтр
+func (f Filter) Apply(atms []ATM) []ATM {
+	if f.IsZero() {
+		return atms
+	}
+	out := []ATM{}
+	for _, atm := range atms {
+		if f.Match(atm) {
+			out = append(out, atm)
+		}
+	}
+	return out
+}
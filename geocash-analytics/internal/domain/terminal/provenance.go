@@ -0,0 +1,50 @@
+package terminal
+
+import "time"
+
+// Происхождение данных, от надежного к ненадежному
+const (
+	ProvenanceReal      = "real"      // наблюдаемые данные: OSM, справочник, обход, операционная статистика
+	ProvenanceEstimated = "estimated" // расчет по косвенным данным (потоки конкурента по средним нашей сети)
+	ProvenanceSynthetic = "synthetic" // сгенерировано случайно (демо-режим)
+	// Показатели неизвестны: сгенерированные сброшены вне демо-режима.
+	// На итог не влияет - неизвестные показатели в ответ не попадают.
+	ProvenanceUnknown = "unknown"
+)
+
+// Provenance - откуда взяты данные банкомата. Точка и показатели (кассеты,
+// простои, потоки) могут быть разного происхождения: реальный банкомат из OSM
+// в демо-режиме получает случайные кассеты.
+type Provenance struct {
+	Kind     string    `json:"kind"`              // итог: худшее из Location и Metrics
+	Location string    `json:"location"`          // сама точка
+	Metrics  string    `json:"metrics,omitempty"` // показатели; пусто - показателей нет
+	AsOf     time.Time `json:"asOf"`              // когда точка получена из источника
+}
+
+// Resolve пересчитывает Kind после изменения Location или Metrics
+func (p *Provenance) Resolve() {
+	p.Kind = WorstProvenance(p.Location, p.Metrics)
+}
+
+// WorstProvenance - наименее надежное из происхождений (пустые пропускаются)
+func WorstProvenance(kinds ...string) string {
+	worst := ProvenanceReal
+	for _, k := range kinds {
+		if provenanceRank(k) > provenanceRank(worst) {
+			worst = k
+		}
+	}
+	return worst
+}
+
+func provenanceRank(kind string) int {
+	switch kind {
+	case ProvenanceEstimated:
+		return 1
+	case ProvenanceSynthetic:
+		return 2
+	default:
+		return 0
+	}
+}
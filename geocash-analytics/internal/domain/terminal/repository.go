@@ -1,21 +1,35 @@
 package terminal
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
+	"strconv"
+	"strings"
 	"time"
+
+	"geocash/internal/domain/city"
 )
 
+// SourceMock - случайные банкоматы GenerateRandomCompetitors
+const SourceMock = "mock"
+
+// ErrNotFound - терминал не найден во внутренних данных банка
+var ErrNotFound = errors.New("терминал не найден")
+
 // Repository - интерфейс (контракт), по которому мы работаем с данными
 type Repository interface {
-	// EnrichATM - наполняет банкомат Forte детальной внутренней статистикой
-	EnrichATM(atm *ATM)
+	// EnrichATM - наполняет банкомат Forte детальной внутренней статистикой.
+	// Заполненный TerminalID (банкомат из справочника) используется как есть, иначе
+	// точка сопоставляется с ближайшим терминалом; ErrNotFound - такого терминала нет.
+	EnrichATM(ctx context.Context, atm *ATM) error
 
 	// EnrichCompetitor - наполняет банкомат конкурента оценочной аналитикой
-	EnrichCompetitor(atm *ATM)
+	EnrichCompetitor(ctx context.Context, atm *ATM) error
 
-	// GenerateRandomCompetitors - создает фейковые точки, если OpenStreetMap недоступен
-	GenerateRandomCompetitors(count int) []ATM
+	// GenerateRandomCompetitors - создает фейковые точки в городе, если OpenStreetMap недоступен
+	GenerateRandomCompetitors(c city.City, count int) []ATM
 }
 
 // MockRepository - имитация базы данных
@@ -26,7 +40,7 @@ func NewMockRepository() *MockRepository {
 }
 
 // --- 1. ЛОГИКА ДЛЯ FORTE (Детальная) ---
-func (r *MockRepository) EnrichATM(atm *ATM) {
+func (r *MockRepository) EnrichATM(ctx context.Context, atm *ATM) error {
 	// Присваиваем признаки Forte
 	atm.IsForte = true
 	atm.Bank = "Forte Bank"
@@ -47,11 +61,13 @@ func (r *MockRepository) EnrichATM(atm *ATM) {
 	atm.Complaints = r.genComplaints()
 
 	// Рассчитываем эффективность на основе сгенерированных данных
-	r.calcEfficiency(atm)
+	atm.EfficiencyStatus = EvaluateEfficiency(atm)
+	atm.Provenance.Metrics = ProvenanceSynthetic
+	return nil
 }
 
 // --- 2. ЛОГИКА ДЛЯ КОНКУРЕНТОВ (Оценочная) ---
-func (r *MockRepository) EnrichCompetitor(atm *ATM) {
+func (r *MockRepository) EnrichCompetitor(ctx context.Context, atm *ATM) error {
 	atm.IsForte = false
 
 	// Генерируем ТОЛЬКО оценочные потоки (Estimated Flows)
@@ -61,29 +77,38 @@ func (r *MockRepository) EnrichCompetitor(atm *ATM) {
 
 	// Оценка Внесения: от 500к до 8 млн в день
 	atm.EstDepositKZT = float64(500000 + rand.Intn(7500000))
+	atm.Provenance.Metrics = ProvenanceSynthetic
 
 	// Остальные поля (Status, Cassettes, Complaints) остаются пустыми,
 	// так как у нас нет доступа к внутренней кухне конкурентов.
+	return nil
 }
 
 // --- 3. FALLBACK ГЕНЕРАТОР (Если нет интернета/OSM) ---
-func (r *MockRepository) GenerateRandomCompetitors(count int) []ATM {
+func (r *MockRepository) GenerateRandomCompetitors(c city.City, count int) []ATM {
 	var atms []ATM
 	banks := []string{"Kaspi", "Halyk", "Jusan", "BCC", "Eurasian"}
 
-	// Границы Астаны для рандома
-	minLat, maxLat := 51.08, 51.20
-	minLng, maxLng := 71.38, 71.52
+	// Центральная половина bbox города: по краям bbox обычно пригороды и степь
+	dLat := (c.BBox.MaxLat - c.BBox.MinLat) / 4
+	dLng := (c.BBox.MaxLng - c.BBox.MinLng) / 4
+	minLat, maxLat := c.Center.Lat-dLat, c.Center.Lat+dLat
+	minLng, maxLng := c.Center.Lng-dLng, c.Center.Lng+dLng
 
 	for i := 0; i < count; i++ {
 		bank := banks[rand.Intn(len(banks))]
 		atms = append(atms, ATM{
-			ID:      9000 + i,
+			ID:      SourceMock + ":" + strconv.Itoa(i),
 			Name:    fmt.Sprintf("%s ATM #%d", bank, i),
 			Lat:     minLat + rand.Float64()*(maxLat-minLat),
 			Lng:     minLng + rand.Float64()*(maxLng-minLng),
 			IsForte: false,
 			Bank:    bank,
+			BankID:  strings.ToLower(bank),
+			Source:  SourceMock,
+			Provenance: Provenance{
+				Kind: ProvenanceSynthetic, Location: ProvenanceSynthetic, Metrics: ProvenanceSynthetic, AsOf: time.Now(),
+			},
 			// Сразу заполняем оценочными данными
 			EstWithdrawalKZT: float64(2000000 + rand.Intn(10000000)),
 			EstDepositKZT:    float64(1000000 + rand.Intn(5000000)),
@@ -99,25 +124,16 @@ func (r *MockRepository) genCassettes() ([]Cassette, float64) {
 	// Кассета Выдачи (Out)
 	capOut := 20000000.0
 	amtOut := float64(rand.Intn(int(capOut)))
-	stOut := "OK"
-	if amtOut < 2000000 {
-		stOut = "Low (Мало денег)"
-	}
-	if amtOut == 0 {
-		stOut = "Empty (Пусто)"
-	}
+	stOut := CassetteStatus(CassetteCashOut, amtOut, capOut)
 
 	// Кассета Приема (In)
 	capIn := 10000000.0
 	amtIn := float64(rand.Intn(int(capIn)))
-	stIn := "OK"
-	if amtIn > 9000000 {
-		stIn = "Full (Переполнен)"
-	}
+	stIn := CassetteStatus(CassetteCashIn, amtIn, capIn)
 
 	list := []Cassette{
-		{Type: "Cash-Out", Currency: "KZT", Amount: amtOut, Capacity: capOut, Status: stOut},
-		{Type: "Cash-In", Currency: "KZT", Amount: amtIn, Capacity: capIn, Status: stIn},
+		{Type: CassetteCashOut, Currency: "KZT", Amount: amtOut, Capacity: capOut, Status: stOut},
+		{Type: CassetteCashIn, Currency: "KZT", Amount: amtIn, Capacity: capIn, Status: stIn},
 	}
 	return list, amtOut + amtIn
 }
@@ -154,21 +170,3 @@ func (r *MockRepository) genComplaints() []Complaint {
 	}
 	return res
 }
-
-// Расчет эффективности (Effective / Ineffective / Normal)
-func (r *MockRepository) calcEfficiency(atm *ATM) {
-	// Проверяем критические статусы кассет
-	cashOutStatus := atm.Cassettes[0].Status
-	cashInStatus := atm.Cassettes[1].Status
-
-	if cashOutStatus == "Empty (Пусто)" || cashInStatus == "Full (Переполнен)" || atm.DowntimePct > 0.10 {
-		// Если нет денег, переполнен или часто ломается
-		atm.EfficiencyStatus = "Ineffective"
-	} else if atm.WithdrawalFreqPerDay > 300 && atm.DowntimePct < 0.03 {
-		// Если много транзакций и редко ломается
-		atm.EfficiencyStatus = "Effective"
-	} else {
-		// Обычный режим
-		atm.EfficiencyStatus = "Normal"
-	}
-}
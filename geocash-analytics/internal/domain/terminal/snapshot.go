@@ -0,0 +1,48 @@
+package terminal
+
+import (
+	"context"
+	"time"
+)
+
+// Виды изменений сети между снимками
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeMoved   = "moved"
+)
+
+// NetworkChange - банкомат, появившийся, пропавший или перенесенный между двумя снимками
+type NetworkChange struct {
+	Change     string    `json:"change"`
+	OSMID      string    `json:"osmId"`
+	BankID     string    `json:"bankId,omitempty"`
+	Bank       string    `json:"bank"`
+	Name       string    `json:"name"`
+	Lat        float64   `json:"lat"` // для removed - последнее известное место
+	Lng        float64   `json:"lng"`
+	PrevLat    float64   `json:"prevLat,omitempty"` // только для moved
+	PrevLng    float64   `json:"prevLng,omitempty"`
+	MovedM     float64   `json:"movedM,omitempty"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// SnapshotStats - итог сохранения снимка
+type SnapshotStats struct {
+	SnapshotID int64
+	ATMs       int
+	// First - предыдущего снимка нет, сравнивать не с чем
+	First bool
+	// Unchanged - сеть не изменилась, снимок не сохранен (SnapshotID - предыдущий)
+	Unchanged             bool
+	Added, Removed, Moved int
+}
+
+// SnapshotStore хранит снимки источников и изменения сети между ними
+type SnapshotStore interface {
+	// SaveSnapshot сравнивает банкоматы с OSM id (остальные пропускаются) с предыдущим
+	// снимком того же города и источника и сохраняет снимок, только если есть изменения
+	SaveSnapshot(ctx context.Context, cityID, source string, atms []ATM) (SnapshotStats, error)
+	// NetworkChanges - изменения в городе, найденные в [from, to)
+	NetworkChanges(ctx context.Context, cityID string, from, to time.Time) ([]NetworkChange, error)
+}
@@ -0,0 +1,45 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"geocash/internal/domain/city"
+)
+
+// Source - источник банкоматов города: OSM, справочник терминалов банка, файл обхода
+type Source interface {
+	// Name - имя источника, попадает в ATM.Source
+	Name() string
+	// FetchATMs - банкоматы в границах города; ошибка - *SourceError
+	FetchATMs(ctx context.Context, c city.City) ([]ATM, error)
+}
+
+// Виды ошибок источника (проверяются через errors.Is)
+var (
+	// ErrSourceUnavailable - сеть, файл или БД недоступны, можно повторить позже
+	ErrSourceUnavailable = errors.New("источник недоступен")
+	// ErrSourceBadData - источник ответил, но данные не разобрать
+	ErrSourceBadData = errors.New("некорректные данные источника")
+)
+
+// SourceError - ошибка источника банкоматов
+type SourceError struct {
+	Source string
+	Kind   error // ErrSourceUnavailable или ErrSourceBadData
+	Err    error
+}
+
+// NewSourceError - ошибка kind в источнике source
+func NewSourceError(source string, kind, err error) *SourceError {
+	return &SourceError{Source: source, Kind: kind, Err: err}
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%s: %v: %v", e.Source, e.Kind, e.Err)
+}
+
+func (e *SourceError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
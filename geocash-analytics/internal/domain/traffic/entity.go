@@ -2,7 +2,54 @@ package traffic
 
 // TrafficSegment - модель одной строки из CSV
 type TrafficSegment struct {
-	EdgeID         int64
-	WeekdayTraffic int
-	Geometry       string // WKT строка
+	EdgeID int64
+	// EdgeIDApprox - ID записан в экспоненциальной форме (1.91E+16): младшие
+	// разряды потеряны, и разные ребра могут получить один ID
+	EdgeIDApprox   bool
+	WeekdayTraffic int // среднесуточный трафик в будний день
+	WeekendTraffic int // среднесуточный трафик в выходной
+	// Профили по часам: доля суточного трафика в каждом часе 0..23 (сумма = 1).
+	// nil - поставщик профиль не дал, трафик считается равномерным.
+	WeekdayHourly []float64
+	WeekendHourly []float64
+	Geometry      string // WKT строка
+	Line          int    // Номер строки в исходном файле (для отчета об ошибках)
+}
+
+// HourlyTraffic раскладывает суточный трафик по часам согласно профилю
+// (без профиля - поровну на каждый час)
+func HourlyTraffic(daily int, profile []float64) []float64 {
+	out := make([]float64, HoursPerDay)
+	for h := range out {
+		if len(profile) == HoursPerDay {
+			out[h] = float64(daily) * profile[h]
+		} else {
+			out[h] = float64(daily) / HoursPerDay
+		}
+	}
+	return out
+}
+
+// Reject - отброшенная при импорте строка
+type Reject struct {
+	Line   int    // номер строки файла (заголовок - строка 1)
+	Column string // колонка, в которой ошибка ("" - строка целиком)
+	Reason string
+}
+
+// SegmentSource - потоковый источник сегментов (например, CSV-файл).
+// Next возвращает io.EOF, когда сегменты закончились.
+type SegmentSource interface {
+	Next() (TrafficSegment, error)
+}
+
+// ImportStats - итог импорта трафика
+type ImportStats struct {
+	SegmentsRead     int `json:"segmentsRead"`     // строк прочитано из файла
+	SegmentsRejected int `json:"segmentsRejected"` // строк отброшено при разборе
+	SegmentsLoaded   int `json:"segmentsLoaded"`   // сегментов загружено в БД
+	SegmentsMatched  int `json:"segmentsMatched"`  // сегментов, попавших хотя бы в одну зону
+	ZonesUpdated     int `json:"zonesUpdated"`     // зон с обновленным трафиком
+
+	RejectsFile string `json:"rejectsFile,omitempty"` // файл с отброшенными строками
 }
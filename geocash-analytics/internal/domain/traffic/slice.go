@@ -0,0 +1,89 @@
+package traffic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HoursPerDay - длина почасового профиля
+const HoursPerDay = 24
+
+// Тип дня для среза трафика
+const (
+	DayAll     = "all"     // средняя неделя: (5 будних + 2 выходных) / 7
+	DayWeekday = "weekday" // будний день
+	DayWeekend = "weekend" // выходной
+)
+
+// TimeSlice - срез трафика по типу дня и диапазону часов [FromHour, ToHour)
+type TimeSlice struct {
+	Day      string `json:"day"`
+	FromHour int    `json:"fromHour"`
+	ToHour   int    `json:"toHour"`
+}
+
+// AllDay - сутки средней недели (срез по умолчанию)
+var AllDay = TimeSlice{Day: DayAll, FromHour: 0, ToHour: HoursPerDay}
+
+// ParseTimeSlice разбирает параметры запроса: day = all|weekday|weekend,
+// hours = "7-10" (с 7:00 до 10:00). Пустые значения - весь день средней недели.
+func ParseTimeSlice(day, hours string) (TimeSlice, error) {
+	s := AllDay
+	if day != "" {
+		s.Day = strings.ToLower(day)
+	}
+	switch s.Day {
+	case DayAll, DayWeekday, DayWeekend:
+	default:
+		return TimeSlice{}, fmt.Errorf("параметр day: допустимо %s, %s, %s, получено %q", DayAll, DayWeekday, DayWeekend, day)
+	}
+
+	if hours != "" {
+		from, to, ok := strings.Cut(hours, "-")
+		f, err1 := strconv.Atoi(strings.TrimSpace(from))
+		t, err2 := strconv.Atoi(strings.TrimSpace(to))
+		if !ok || err1 != nil || err2 != nil {
+			return TimeSlice{}, fmt.Errorf("параметр hours: ожидается диапазон вида 7-10, получено %q", hours)
+		}
+		s.FromHour, s.ToHour = f, t
+	}
+	if s.FromHour < 0 || s.ToHour > HoursPerDay || s.FromHour >= s.ToHour {
+		return TimeSlice{}, fmt.Errorf("параметр hours: нужен диапазон внутри 0-24 и начало раньше конца, получено %q", hours)
+	}
+	return s, nil
+}
+
+// Hours - длина среза в часах
+func (s TimeSlice) Hours() int {
+	return s.ToHour - s.FromHour
+}
+
+func (s TimeSlice) String() string {
+	return fmt.Sprintf("%s %02d:00-%02d:00", s.Day, s.FromHour, s.ToHour)
+}
+
+// Traffic - трафик в срезе по суточным значениям и почасовым профилям
+// (абсолютные значения по часам, nil - равномерно). Та же формула, что у
+// SQL-функции traffic_slice.
+func (s TimeSlice) Traffic(weekdayHourly []float64, weekdayDaily float64, weekendHourly []float64, weekendDaily float64) float64 {
+	switch s.Day {
+	case DayWeekday:
+		return s.hours(weekdayHourly, weekdayDaily)
+	case DayWeekend:
+		return s.hours(weekendHourly, weekendDaily)
+	default:
+		return (5*s.hours(weekdayHourly, weekdayDaily) + 2*s.hours(weekendHourly, weekendDaily)) / 7
+	}
+}
+
+func (s TimeSlice) hours(hourly []float64, daily float64) float64 {
+	if len(hourly) != HoursPerDay {
+		return daily * float64(s.Hours()) / HoursPerDay
+	}
+	total := 0.0
+	for _, v := range hourly[s.FromHour:s.ToHour] {
+		total += v
+	}
+	return total
+}
@@ -0,0 +1,27 @@
+package traffic
+
+import (
+	"context"
+
+	"geocash/internal/geo"
+)
+
+// ZoneTraffic - зона geo_traffic_zones с трафиком в заданном срезе
+type ZoneTraffic struct {
+	ID           int         `json:"id"`
+	Code         string      `json:"code,omitempty"` // код ячейки сетки (astana-hex-r012c034), пусто у ручных зон
+	Name         string      `json:"name"`
+	Polygon      [][]float64 `json:"polygon"` // внешнее кольцо, точки [lng, lat]
+	TrafficScore int         `json:"trafficScore"`
+	// Traffic - среднесуточный трафик зоны внутри среза (сумма по часам среза)
+	Traffic float64 `json:"traffic"`
+}
+
+// ZoneRepository - трафик по зонам (реализация: postgres.ZoneRepository)
+type ZoneRepository interface {
+	// ZonesTraffic - зоны с данными трафика, пересекающие area (bbox города)
+	ZonesTraffic(ctx context.Context, area geo.Bounds, slice TimeSlice) ([]ZoneTraffic, error)
+	// ExpansionCandidates - зоны в area без наших терминалов, самые оживленные в срезе первыми.
+	// Терминалы из closed (terminal_id, закрытые в нужный момент) зону не покрывают.
+	ExpansionCandidates(ctx context.Context, area geo.Bounds, slice TimeSlice, closed []string, limit int) ([]ZoneTraffic, error)
+}
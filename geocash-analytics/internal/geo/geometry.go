@@ -0,0 +1,109 @@
+// Package geo - минимальная работа с геометрией дорожных сегментов:
+// разбор WKB/WKT, вывод в WKT для PostGIS и пересечение линий с полигонами
+// без PostGIS (для импорта трафика в память)
+package geo
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Point - точка WGS84 (X = долгота, Y = широта, как в WKT)
+type Point struct {
+	Lng float64
+	Lat float64
+}
+
+// LineString - ломаная
+type LineString []Point
+
+// MultiLineString - набор ломаных
+type MultiLineString []LineString
+
+// Geometry - геометрия, которую можно записать в WKT
+type Geometry interface {
+	WKT() string
+	Bounds() Bounds
+}
+
+// Bounds - ограничивающий прямоугольник
+type Bounds struct {
+	MinLng, MinLat, MaxLng, MaxLat float64
+}
+
+// Intersects - пересекаются ли прямоугольники
+func (b Bounds) Intersects(o Bounds) bool {
+	return b.MinLng <= o.MaxLng && o.MinLng <= b.MaxLng && b.MinLat <= o.MaxLat && o.MinLat <= b.MaxLat
+}
+
+// Contains - лежит ли точка внутри прямоугольника (включая границу)
+func (b Bounds) Contains(p Point) bool {
+	return p.Lng >= b.MinLng && p.Lng <= b.MaxLng && p.Lat >= b.MinLat && p.Lat <= b.MaxLat
+}
+
+// Valid - координаты в допустимых пределах WGS84
+func (b Bounds) Valid() bool {
+	return b.MinLat >= -90 && b.MaxLat <= 90 && b.MinLng >= -180 && b.MaxLng <= 180
+}
+
+func boundsOf(pts []Point) Bounds {
+	if len(pts) == 0 {
+		return Bounds{}
+	}
+	b := Bounds{MinLng: pts[0].Lng, MinLat: pts[0].Lat, MaxLng: pts[0].Lng, MaxLat: pts[0].Lat}
+	for _, p := range pts[1:] {
+		b = b.extend(p)
+	}
+	return b
+}
+
+func (b Bounds) extend(p Point) Bounds {
+	b.MinLng = math.Min(b.MinLng, p.Lng)
+	b.MinLat = math.Min(b.MinLat, p.Lat)
+	b.MaxLng = math.Max(b.MaxLng, p.Lng)
+	b.MaxLat = math.Max(b.MaxLat, p.Lat)
+	return b
+}
+
+func (p Point) Bounds() Bounds {
+	return Bounds{MinLng: p.Lng, MinLat: p.Lat, MaxLng: p.Lng, MaxLat: p.Lat}
+}
+
+func (l LineString) Bounds() Bounds { return boundsOf(l) }
+
+func (m MultiLineString) Bounds() Bounds {
+	var all []Point
+	for _, l := range m {
+		all = append(all, l...)
+	}
+	return boundsOf(all)
+}
+
+func (p Point) WKT() string {
+	return "POINT(" + formatPoint(p) + ")"
+}
+
+func (l LineString) WKT() string {
+	return "LINESTRING" + formatPoints(l)
+}
+
+func (m MultiLineString) WKT() string {
+	parts := make([]string, len(m))
+	for i, l := range m {
+		parts[i] = formatPoints(l)
+	}
+	return "MULTILINESTRING(" + strings.Join(parts, ",") + ")"
+}
+
+func formatPoint(p Point) string {
+	return strconv.FormatFloat(p.Lng, 'f', -1, 64) + " " + strconv.FormatFloat(p.Lat, 'f', -1, 64)
+}
+
+func formatPoints(pts []Point) string {
+	parts := make([]string, len(pts))
+	for i, p := range pts {
+		parts[i] = formatPoint(p)
+	}
+	return "(" + strings.Join(parts, ",") + ")"
+}
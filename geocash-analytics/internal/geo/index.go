@@ -0,0 +1,49 @@
+package geo
+
+import "math"
+
+// GridIndex - пространственный индекс на равномерной сетке: каждый объект
+// лежит во всех ячейках, которые задевает его прямоугольник
+type GridIndex struct {
+	cell  float64 // размер ячейки в градусах
+	cells map[[2]int][]int
+}
+
+// NewGridIndex: cellSize - сторона ячейки в градусах (порядка размера объектов)
+func NewGridIndex(cellSize float64) *GridIndex {
+	return &GridIndex{cell: cellSize, cells: make(map[[2]int][]int)}
+}
+
+// Insert добавляет объект id с ограничивающим прямоугольником b
+func (ix *GridIndex) Insert(id int, b Bounds) {
+	x0, y0, x1, y1 := ix.span(b)
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			k := [2]int{x, y}
+			ix.cells[k] = append(ix.cells[k], id)
+		}
+	}
+}
+
+// Query - id объектов, чьи ячейки пересекаются с b (кандидаты, без точной проверки)
+func (ix *GridIndex) Query(b Bounds) []int {
+	x0, y0, x1, y1 := ix.span(b)
+	seen := make(map[int]bool)
+	var ids []int
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			for _, id := range ix.cells[[2]int{x, y}] {
+				if !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+func (ix *GridIndex) span(b Bounds) (x0, y0, x1, y1 int) {
+	return int(math.Floor(b.MinLng / ix.cell)), int(math.Floor(b.MinLat / ix.cell)),
+		int(math.Floor(b.MaxLng / ix.cell)), int(math.Floor(b.MaxLat / ix.cell))
+}
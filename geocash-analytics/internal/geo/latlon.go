@@ -0,0 +1,47 @@
+package geo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LatLonPairSeparators - разделители точек в ParseLatLonPairs
+const LatLonPairSeparators = ";|"
+
+// ParseLatLonPairs разбирает последовательность точек "широта долгота"
+// через ';' или '|': "51.12 71.43; 51.13 71.44". Если decimalComma = false,
+// внутри пары допустима и запятая: "51.12,71.43;51.13,71.44".
+func ParseLatLonPairs(s string, decimalComma bool) (LineString, error) {
+	pairs := strings.FieldsFunc(s, func(r rune) bool { return strings.ContainsRune(LatLonPairSeparators, r) })
+	if len(pairs) < 2 {
+		return nil, fmt.Errorf("нужно минимум 2 точки, получено %d", len(pairs))
+	}
+
+	line := make(LineString, 0, len(pairs))
+	for i, pair := range pairs {
+		var parts []string
+		if decimalComma {
+			parts = strings.Fields(pair)
+		} else {
+			parts = strings.FieldsFunc(pair, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		}
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("точка %d: ожидается пара \"широта долгота\", получено %q", i+1, strings.TrimSpace(pair))
+		}
+
+		var coords [2]float64
+		for j, p := range parts {
+			if decimalComma {
+				p = strings.Replace(p, ",", ".", 1)
+			}
+			v, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return nil, fmt.Errorf("точка %d: некорректное число %q", i+1, parts[j])
+			}
+			coords[j] = v
+		}
+		line = append(line, Point{Lat: coords[0], Lng: coords[1]})
+	}
+	return line, nil
+}
@@ -0,0 +1,57 @@
+package geo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLatLonPairs(t *testing.T) {
+	want := LineString{{Lat: 51.12, Lng: 71.43}, {Lat: 51.13, Lng: 71.44}}
+	tests := []struct {
+		name         string
+		in           string
+		decimalComma bool
+	}{
+		{"space and semicolon", "51.12 71.43; 51.13 71.44", false},
+		{"pipe", "51.12 71.43|51.13 71.44", false},
+		{"comma inside pair", "51.12,71.43;51.13,71.44", false},
+		{"tab inside pair", "51.12\t71.43;51.13\t71.44", false},
+		{"trailing separator", "51.12 71.43;51.13 71.44;", false},
+		{"decimal comma", "51,12 71,43; 51,13 71,44", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLatLonPairs(tt.in, tt.decimalComma)
+			if err != nil {
+				t.Fatalf("ParseLatLonPairs(%q): %v", tt.in, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ParseLatLonPairs(%q) = %v, want %v", tt.in, got, want)
+			}
+		})
+	}
+}
+
+func TestParseLatLonPairsErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		in           string
+		decimalComma bool
+	}{
+		{"empty", "", false},
+		{"separators only", ";|;", false},
+		{"single point", "51.12 71.43", false},
+		{"three numbers", "51.12 71.43 100; 51.13 71.44", false},
+		{"one number", "51.12; 51.13 71.44", false},
+		{"not a number", "51.12 abc; 51.13 71.44", false},
+		{"comma pair with decimal comma", "51,12,71,43; 51,13,71,44", true},
+		{"two decimal commas", "51,1,2 71,43; 51,13 71,44", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if l, err := ParseLatLonPairs(tt.in, tt.decimalComma); err == nil {
+				t.Errorf("ParseLatLonPairs(%q) = %v, want error", tt.in, l)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package geo
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Polygon - внешнее кольцо и дыры; кольца замкнуты (первая точка = последней)
+type Polygon []Ring
+
+// Ring - замкнутое кольцо полигона
+type Ring []Point
+
+func (p Polygon) Bounds() Bounds {
+	if len(p) == 0 {
+		return Bounds{}
+	}
+	return boundsOf(p[0])
+}
+
+func (p Polygon) WKT() string {
+	parts := make([]string, len(p))
+	for i, r := range p {
+		parts[i] = formatPoints(r)
+	}
+	return "POLYGON(" + strings.Join(parts, ",") + ")"
+}
+
+// Contains - лежит ли точка внутри полигона (в дыре - снаружи).
+// Точка на границе может попасть в любую сторону.
+func (p Polygon) Contains(pt Point) bool {
+	if len(p) == 0 || !p.Bounds().Intersects(pt.Bounds()) {
+		return false
+	}
+	inside := false
+	for _, r := range p {
+		if r.contains(pt) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// contains - четно-нечетное правило (луч вдоль долготы)
+func (r Ring) contains(pt Point) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		a, b := r[i], r[j]
+		if (a.Lat > pt.Lat) != (b.Lat > pt.Lat) &&
+			pt.Lng < (b.Lng-a.Lng)*(pt.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// boundaryEps - допуск попадания на ребро, в градусах (доли миллиметра)
+const boundaryEps = 1e-12
+
+// onBoundary - лежит ли точка на ребре одного из колец
+func (p Polygon) onBoundary(pt Point) bool {
+	for _, r := range p {
+		for i := 1; i < len(r); i++ {
+			a, b := r[i-1], r[i]
+			cross := (b.Lng-a.Lng)*(pt.Lat-a.Lat) - (b.Lat-a.Lat)*(pt.Lng-a.Lng)
+			if math.Abs(cross) > boundaryEps {
+				continue
+			}
+			if pt.Lng >= math.Min(a.Lng, b.Lng)-boundaryEps && pt.Lng <= math.Max(a.Lng, b.Lng)+boundaryEps &&
+				pt.Lat >= math.Min(a.Lat, b.Lat)-boundaryEps && pt.Lat <= math.Max(a.Lat, b.Lat)+boundaryEps {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ClipLength - длина части линии внутри полигона, в метрах.
+// Аналог ST_Length(ST_Intersection(polygon, line)::geography) для коротких сегментов.
+func (p Polygon) ClipLength(g Geometry) float64 {
+	switch l := g.(type) {
+	case LineString:
+		return p.clipLine(l)
+	case MultiLineString:
+		total := 0.0
+		for _, part := range l {
+			total += p.clipLine(part)
+		}
+		return total
+	default:
+		return 0
+	}
+}
+
+func (p Polygon) clipLine(l LineString) float64 {
+	if !p.Bounds().Intersects(l.Bounds()) {
+		return 0
+	}
+	total := 0.0
+	for i := 1; i < len(l); i++ {
+		total += p.clipSegment(l[i-1], l[i])
+	}
+	return total
+}
+
+// clipSegment режет отрезок a-b точками пересечения с ребрами полигона и
+// суммирует куски, середина которых внутри или на границе: как и в
+// ST_Intersection, граница - часть полигона, поэтому отрезок вдоль ребра
+// засчитывается целиком, а касание в одной точке дает 0
+func (p Polygon) clipSegment(a, b Point) float64 {
+	ts := []float64{0, 1}
+	for _, r := range p {
+		for i := 1; i < len(r); i++ {
+			if t, ok := segmentIntersection(a, b, r[i-1], r[i]); ok {
+				ts = append(ts, t)
+			}
+		}
+	}
+	sort.Float64s(ts)
+
+	total := 0.0
+	for i := 1; i < len(ts); i++ {
+		if ts[i]-ts[i-1] < 1e-12 {
+			continue
+		}
+		from, to := lerp(a, b, ts[i-1]), lerp(a, b, ts[i])
+		if mid := lerp(a, b, (ts[i-1]+ts[i])/2); p.onBoundary(mid) || p.Contains(mid) {
+			total += Distance(from, to)
+		}
+	}
+	return total
+}
+
+// segmentIntersection - параметр t на отрезке a-b, где он пересекает c-d
+func segmentIntersection(a, b, c, d Point) (float64, bool) {
+	rx, ry := b.Lng-a.Lng, b.Lat-a.Lat
+	sx, sy := d.Lng-c.Lng, d.Lat-c.Lat
+	den := rx*sy - ry*sx
+	if den == 0 {
+		return 0, false // параллельны: отрезок вдоль ребра режется его концами из других ребер
+	}
+	qx, qy := c.Lng-a.Lng, c.Lat-a.Lat
+	t := (qx*sy - qy*sx) / den
+	u := (qx*ry - qy*rx) / den
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return 0, false
+	}
+	return t, true
+}
+
+func lerp(a, b Point, t float64) Point {
+	return Point{Lng: a.Lng + (b.Lng-a.Lng)*t, Lat: a.Lat + (b.Lat-a.Lat)*t}
+}
+
+// Радиус Земли (как у сферы в PostGIS geography с use_spheroid = false)
+const earthRadiusM = 6371008.8
+
+// Distance - расстояние между точками по большому кругу, в метрах
+func Distance(a, b Point) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusM * math.Asin(math.Sqrt(h))
+}
+
+// Length - длина линии в метрах
+func Length(g Geometry) float64 {
+	switch l := g.(type) {
+	case LineString:
+		total := 0.0
+		for i := 1; i < len(l); i++ {
+			total += Distance(l[i-1], l[i])
+		}
+		return total
+	case MultiLineString:
+		total := 0.0
+		for _, part := range l {
+			total += Length(part)
+		}
+		return total
+	default:
+		return 0
+	}
+}
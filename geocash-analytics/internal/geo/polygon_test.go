@@ -0,0 +1,96 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+// square - квадрат 0.01° x 0.01° у Астаны (около 700 x 1100 м)
+var square = Polygon{{
+	{Lng: 71.40, Lat: 51.10}, {Lng: 71.41, Lat: 51.10}, {Lng: 71.41, Lat: 51.11}, {Lng: 71.40, Lat: 51.11}, {Lng: 71.40, Lat: 51.10},
+}}
+
+func pt(lng, lat float64) Point { return Point{Lng: lng, Lat: lat} }
+
+func TestClipLength(t *testing.T) {
+	tests := []struct {
+		name string
+		line Geometry
+		want float64
+	}{
+		{"inside", LineString{pt(71.402, 51.105), pt(71.408, 51.105)}, Distance(pt(71.402, 51.105), pt(71.408, 51.105))},
+		{"outside", LineString{pt(71.42, 51.105), pt(71.43, 51.105)}, 0},
+		{"outside bbox overlap", LineString{pt(71.39, 51.09), pt(71.395, 51.12)}, 0},
+		{"crossing", LineString{pt(71.39, 51.105), pt(71.42, 51.105)}, Distance(pt(71.40, 51.105), pt(71.41, 51.105))},
+		{"inside to outside", LineString{pt(71.405, 51.105), pt(71.42, 51.105)}, Distance(pt(71.405, 51.105), pt(71.41, 51.105))},
+		{"through vertices", LineString{pt(71.39, 51.09), pt(71.42, 51.12)}, Distance(pt(71.40, 51.10), pt(71.41, 51.11))},
+		{"along bottom edge", LineString{pt(71.40, 51.10), pt(71.41, 51.10)}, Distance(pt(71.40, 51.10), pt(71.41, 51.10))},
+		{"along top edge", LineString{pt(71.40, 51.11), pt(71.41, 51.11)}, Distance(pt(71.40, 51.11), pt(71.41, 51.11))},
+		{"partly along edge", LineString{pt(71.39, 51.11), pt(71.405, 51.11)}, Distance(pt(71.40, 51.11), pt(71.405, 51.11))},
+		{"touches edge from outside", LineString{pt(71.405, 51.09), pt(71.405, 51.10)}, 0},
+		{"touches vertex from outside", LineString{pt(71.39, 51.09), pt(71.40, 51.10)}, 0},
+		{"bends at vertex outside", LineString{pt(71.39, 51.10), pt(71.40, 51.10), pt(71.40, 51.09)}, 0},
+		{
+			"multilinestring",
+			MultiLineString{
+				{pt(71.39, 51.105), pt(71.42, 51.105)},
+				{pt(71.42, 51.105), pt(71.43, 51.105)},
+			},
+			Distance(pt(71.40, 51.105), pt(71.41, 51.105)),
+		},
+		{"point", pt(71.405, 51.105), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := square.ClipLength(tt.line)
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("ClipLength = %.6f м, want %.6f м", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClipLengthHole(t *testing.T) {
+	withHole := Polygon{square[0], {
+		{Lng: 71.404, Lat: 51.104}, {Lng: 71.406, Lat: 51.104}, {Lng: 71.406, Lat: 51.106}, {Lng: 71.404, Lat: 51.106}, {Lng: 71.404, Lat: 51.104},
+	}}
+	line := LineString{pt(71.39, 51.105), pt(71.42, 51.105)}
+	want := Distance(pt(71.40, 51.105), pt(71.404, 51.105)) + Distance(pt(71.406, 51.105), pt(71.41, 51.105))
+	if got := withHole.ClipLength(line); math.Abs(got-want) > 1e-6 {
+		t.Errorf("ClipLength = %.6f м, want %.6f м", got, want)
+	}
+}
+
+func TestPolygonContains(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Point
+		want bool
+	}{
+		{"center", pt(71.405, 51.105), true},
+		{"outside", pt(71.42, 51.105), false},
+		{"outside bbox", pt(0, 0), false},
+	}
+	for _, tt := range tests {
+		if got := square.Contains(tt.p); got != tt.want {
+			t.Errorf("%s: Contains(%v) = %v, want %v", tt.name, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestGridIndex(t *testing.T) {
+	ix := NewGridIndex(0.01)
+	ix.Insert(0, square.Bounds())
+	ix.Insert(1, Bounds{MinLng: 71.50, MinLat: 51.20, MaxLng: 71.51, MaxLat: 51.21})
+
+	got := ix.Query(LineString{pt(71.405, 51.105), pt(71.406, 51.106)}.Bounds())
+	if len(got) != 1 || got[0] != 0 {
+		t.Errorf("Query = %v, want [0]", got)
+	}
+	if got := ix.Query(Bounds{MinLng: 71.39, MinLat: 51.09, MaxLng: 71.52, MaxLat: 51.22}); len(got) != 2 {
+		t.Errorf("Query over both = %v, want 2 ids without duplicates", got)
+	}
+	if got := ix.Query(Bounds{MinLng: 10, MinLat: 10, MaxLng: 10.001, MaxLat: 10.001}); len(got) != 0 {
+		t.Errorf("Query far away = %v, want none", got)
+	}
+}
@@ -0,0 +1,161 @@
+package geo
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Коды типов WKB (ISO и EWKB PostGIS)
+const (
+	wkbPoint           = 1
+	wkbLineString      = 2
+	wkbMultiLineString = 5
+
+	ewkbSRIDFlag = 0x20000000
+	ewkbZFlag    = 0x80000000
+	ewkbMFlag    = 0x40000000
+)
+
+// DecodeWKBHex разбирает hex-строку WKB/EWKB (так PostGIS выгружает geometry).
+// Поддерживаются Point, LineString и MultiLineString в 2D, Z и M вариантах.
+func DecodeWKBHex(s string) (Geometry, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "\\x"))
+	if err != nil {
+		return nil, fmt.Errorf("некорректный hex WKB: %w", err)
+	}
+	d := &wkbDecoder{data: data}
+	g, err := d.geometry()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("лишние байты в WKB после позиции %d", d.pos)
+	}
+	return g, nil
+}
+
+type wkbDecoder struct {
+	data  []byte
+	pos   int
+	order binary.ByteOrder
+	dims  int
+}
+
+func (d *wkbDecoder) geometry() (Geometry, error) {
+	if err := d.need(5); err != nil {
+		return nil, err
+	}
+	switch d.data[d.pos] {
+	case 0:
+		d.order = binary.BigEndian
+	case 1:
+		d.order = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("некорректный порядок байт WKB: %d", d.data[d.pos])
+	}
+	d.pos++
+
+	typ := d.order.Uint32(d.data[d.pos:])
+	d.pos += 4
+
+	// EWKB: флаги в старших битах, ISO WKB: Z/M как +1000/+2000/+3000
+	d.dims = 2
+	if typ&ewkbZFlag != 0 {
+		d.dims++
+	}
+	if typ&ewkbMFlag != 0 {
+		d.dims++
+	}
+	if typ&ewkbSRIDFlag != 0 {
+		if err := d.need(4); err != nil {
+			return nil, err
+		}
+		d.pos += 4 // SRID пропускаем: геометрия всегда в 4326
+	}
+	typ &^= ewkbZFlag | ewkbMFlag | ewkbSRIDFlag
+	switch typ / 1000 {
+	case 1, 2:
+		d.dims = 3
+	case 3:
+		d.dims = 4
+	}
+	typ %= 1000
+
+	switch typ {
+	case wkbPoint:
+		return d.point()
+	case wkbLineString:
+		return d.lineString()
+	case wkbMultiLineString:
+		n, err := d.uint32()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("пустая геометрия MultiLineString")
+		}
+		m := make(MultiLineString, 0, n)
+		for i := uint32(0); i < n; i++ {
+			g, err := d.geometry()
+			if err != nil {
+				return nil, err
+			}
+			l, ok := g.(LineString)
+			if !ok {
+				return nil, fmt.Errorf("MultiLineString содержит не LineString")
+			}
+			m = append(m, l)
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип WKB: %d", typ)
+	}
+}
+
+func (d *wkbDecoder) lineString() (LineString, error) {
+	n, err := d.uint32()
+	if err != nil {
+		return nil, err
+	}
+	// Как и в WKT: пустая линия или линия из одной точки - не сегмент
+	if n < 2 {
+		return nil, fmt.Errorf("LineString: нужно минимум 2 точки, получено %d", n)
+	}
+	if err := d.need(int(n) * d.dims * 8); err != nil {
+		return nil, err
+	}
+	l := make(LineString, n)
+	for i := range l {
+		l[i], _ = d.point()
+	}
+	return l, nil
+}
+
+func (d *wkbDecoder) point() (Point, error) {
+	if err := d.need(d.dims * 8); err != nil {
+		return Point{}, err
+	}
+	x := math.Float64frombits(d.order.Uint64(d.data[d.pos:]))
+	y := math.Float64frombits(d.order.Uint64(d.data[d.pos+8:]))
+	d.pos += d.dims * 8 // Z и M отбрасываем
+	return Point{Lng: x, Lat: y}, nil
+}
+
+func (d *wkbDecoder) uint32() (uint32, error) {
+	if err := d.need(4); err != nil {
+		return 0, err
+	}
+	v := d.order.Uint32(d.data[d.pos:])
+	d.pos += 4
+	return v, nil
+}
+
+func (d *wkbDecoder) need(n int) error {
+	if n < 0 || d.pos+n > len(d.data) {
+		return fmt.Errorf("WKB обрывается на позиции %d", d.pos)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package geo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeWKBHex(t *testing.T) {
+	line := LineString{{Lng: 71.4, Lat: 51.1}, {Lng: 71.5, Lat: 51.2}}
+	tests := []struct {
+		name string
+		in   string
+		want Geometry
+	}{
+		{"point", "01010000009a99999999d95140cdcccccccc8c4940", Point{Lng: 71.4, Lat: 51.1}},
+		{"little endian", "0102000000020000009a99999999d95140cdcccccccc8c49400000000000e051409a99999999994940", line},
+		{"big endian", "0000000002000000024051d9999999999a40498ccccccccccd4051e00000000000404999999999999a", line},
+		{"ewkb srid", "0102000020e6100000020000009a99999999d95140cdcccccccc8c49400000000000e051409a99999999994940", line},
+		{"ewkb z", "0102000080020000009a99999999d95140cdcccccccc8c494000000000000059400000000000e051409a999999999949400000000000006940", line},
+		{"iso z", "01ea030000020000009a99999999d95140cdcccccccc8c494000000000000059400000000000e051409a999999999949400000000000006940", line},
+		{"postgres bytea prefix", `\x0102000000020000009a99999999d95140cdcccccccc8c49400000000000e051409a99999999994940`, line},
+		{"uppercase hex", "0102000000020000009A99999999D95140CDCCCCCCCC8C49400000000000E051409A99999999994940", line},
+		{
+			"multilinestring",
+			"0105000000020000000102000000020000009a99999999d95140cdcccccccc8c49400000000000e051409a999999999949400102000000020000006666666666e651406666666666a64940cdccccccccec51403333333333b34940",
+			MultiLineString{line, {{Lng: 71.6, Lat: 51.3}, {Lng: 71.7, Lat: 51.4}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeWKBHex(tt.in)
+			if err != nil {
+				t.Fatalf("DecodeWKBHex: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeWKBHex = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeWKBHexErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"empty", ""},
+		{"not hex", "zz"},
+		{"odd length", "010"},
+		{"bad byte order", "0202000000020000009a99999999d95140cdcccccccc8c49400000000000e051409a99999999994940"},
+		{"header only", "01020000"},
+		{"truncated points", "0102000000020000009a99999999d95140cdcccccccc8c4940"},
+		{"trailing bytes", "01010000009a99999999d95140cdcccccccc8c4940ff"},
+		{"empty linestring", "010200000000000000"},
+		{"empty multilinestring", "010500000000000000"},
+		{"multilinestring with point", "01050000000100000001010000009a99999999d95140cdcccccccc8c4940"},
+		{"unsupported polygon", "010300000000000000"},
+		{"huge point count", "0102000000ffffff7f"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if g, err := DecodeWKBHex(tt.in); err == nil {
+				t.Errorf("DecodeWKBHex(%q) = %#v, want error", tt.in, g)
+			}
+		})
+	}
+}
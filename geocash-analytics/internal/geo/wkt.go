@@ -0,0 +1,211 @@
+package geo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseWKT разбирает WKT (в том числе с префиксом EWKT "SRID=4326;").
+// Поддерживаются POINT, LINESTRING, MULTILINESTRING и POLYGON; Z и M координаты отбрасываются.
+func ParseWKT(s string) (Geometry, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(strings.ToUpper(s), "SRID=") {
+		i := strings.IndexByte(s, ';')
+		if i < 0 {
+			return nil, fmt.Errorf("некорректный префикс SRID")
+		}
+		s = s[i+1:]
+	}
+
+	p := &wktParser{s: s}
+	typ := strings.ToUpper(p.word())
+	// Необязательный модификатор размерности: LINESTRING Z (...)
+	if dim := strings.ToUpper(p.peekWord()); dim == "Z" || dim == "M" || dim == "ZM" {
+		p.word()
+	}
+	if strings.ToUpper(p.peekWord()) == "EMPTY" {
+		return nil, fmt.Errorf("пустая геометрия %s", typ)
+	}
+
+	var g Geometry
+	var err error
+	switch typ {
+	case "POINT":
+		var pts []Point
+		pts, err = p.points()
+		if err == nil && len(pts) != 1 {
+			err = fmt.Errorf("POINT должен содержать одну точку")
+		}
+		if err == nil {
+			g = pts[0]
+		}
+	case "LINESTRING":
+		g, err = p.lineString()
+	case "MULTILINESTRING":
+		g, err = p.multiLineString()
+	case "POLYGON":
+		g, err = p.polygon()
+	case "":
+		return nil, fmt.Errorf("пустая строка вместо WKT")
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип геометрии %q", typ)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", typ, err)
+	}
+
+	p.skipSpaces()
+	if p.pos != len(p.s) {
+		return nil, fmt.Errorf("%s: лишние символы после позиции %d", typ, p.pos)
+	}
+	return g, nil
+}
+
+type wktParser struct {
+	s   string
+	pos int
+}
+
+func (p *wktParser) skipSpaces() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n' || p.s[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+// word читает слово из латинских букв
+func (p *wktParser) word() string {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.s) && ((p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z') || (p.s[p.pos] >= 'A' && p.s[p.pos] <= 'Z')) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *wktParser) peekWord() string {
+	pos := p.pos
+	w := p.word()
+	p.pos = pos
+	return w
+}
+
+func (p *wktParser) expect(c byte) error {
+	p.skipSpaces()
+	if p.pos >= len(p.s) {
+		return fmt.Errorf("ожидался %q, строка закончилась", c)
+	}
+	if p.s[p.pos] != c {
+		return fmt.Errorf("ожидался %q на позиции %d, получено %q", c, p.pos, p.s[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+// next - следующий значимый символ без продвижения
+func (p *wktParser) next() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+// points читает "(x y, x y, ...)"
+func (p *wktParser) points() ([]Point, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	var pts []Point
+	for {
+		pt, err := p.point()
+		if err != nil {
+			return nil, err
+		}
+		pts = append(pts, pt)
+		if p.next() != ',' {
+			break
+		}
+		p.pos++
+	}
+	return pts, p.expect(')')
+}
+
+// point читает "x y [z [m]]"
+func (p *wktParser) point() (Point, error) {
+	var coords []float64
+	for len(coords) < 4 {
+		p.skipSpaces()
+		start := p.pos
+		for p.pos < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[p.pos]) >= 0 {
+			p.pos++
+		}
+		if start == p.pos {
+			break
+		}
+		v, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+		if err != nil {
+			return Point{}, fmt.Errorf("некорректное число %q на позиции %d", p.s[start:p.pos], start)
+		}
+		coords = append(coords, v)
+	}
+	if len(coords) < 2 {
+		return Point{}, fmt.Errorf("ожидалась пара координат на позиции %d", p.pos)
+	}
+	return Point{Lng: coords[0], Lat: coords[1]}, nil
+}
+
+func (p *wktParser) lineString() (LineString, error) {
+	pts, err := p.points()
+	if err != nil {
+		return nil, err
+	}
+	if len(pts) < 2 {
+		return nil, fmt.Errorf("нужно минимум 2 точки, получено %d", len(pts))
+	}
+	return LineString(pts), nil
+}
+
+func (p *wktParser) multiLineString() (MultiLineString, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	var m MultiLineString
+	for {
+		l, err := p.lineString()
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, l)
+		if p.next() != ',' {
+			break
+		}
+		p.pos++
+	}
+	return m, p.expect(')')
+}
+
+func (p *wktParser) polygon() (Polygon, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	var poly Polygon
+	for {
+		pts, err := p.points()
+		if err != nil {
+			return nil, err
+		}
+		if len(pts) < 4 {
+			return nil, fmt.Errorf("кольцо %d: нужно минимум 4 точки, получено %d", len(poly), len(pts))
+		}
+		if pts[0] != pts[len(pts)-1] {
+			return nil, fmt.Errorf("кольцо %d не замкнуто", len(poly))
+		}
+		poly = append(poly, Ring(pts))
+		if p.next() != ',' {
+			break
+		}
+		p.pos++
+	}
+	return poly, p.expect(')')
+}
@@ -0,0 +1,99 @@
+package geo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWKT(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want Geometry
+	}{
+		{"point", "POINT(71.43 51.12)", Point{Lng: 71.43, Lat: 51.12}},
+		{"linestring", "LINESTRING(71.43 51.12, 71.44 51.13)", LineString{{Lng: 71.43, Lat: 51.12}, {Lng: 71.44, Lat: 51.13}}},
+		{"lowercase and spaces", "  linestring ( 71.43 51.12 ,71.44 51.13 )  ", LineString{{Lng: 71.43, Lat: 51.12}, {Lng: 71.44, Lat: 51.13}}},
+		{"ewkt srid", "SRID=4326;LINESTRING(71.43 51.12,71.44 51.13)", LineString{{Lng: 71.43, Lat: 51.12}, {Lng: 71.44, Lat: 51.13}}},
+		{"z dropped", "LINESTRING Z (71.43 51.12 500, 71.44 51.13 510)", LineString{{Lng: 71.43, Lat: 51.12}, {Lng: 71.44, Lat: 51.13}}},
+		{"zm without modifier", "LINESTRING(71.43 51.12 500 1, 71.44 51.13 510 2)", LineString{{Lng: 71.43, Lat: 51.12}, {Lng: 71.44, Lat: 51.13}}},
+		{"exponent", "POINT(7.143E+01 5.112e1)", Point{Lng: 71.43, Lat: 51.12}},
+		{
+			"multilinestring",
+			"MULTILINESTRING((71.43 51.12,71.44 51.13),(71.45 51.14,71.46 51.15,71.47 51.16))",
+			MultiLineString{
+				{{Lng: 71.43, Lat: 51.12}, {Lng: 71.44, Lat: 51.13}},
+				{{Lng: 71.45, Lat: 51.14}, {Lng: 71.46, Lat: 51.15}, {Lng: 71.47, Lat: 51.16}},
+			},
+		},
+		{
+			"polygon with hole",
+			"POLYGON((0 0,4 0,4 4,0 4,0 0),(1 1,2 1,2 2,1 1))",
+			Polygon{
+				{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}},
+				{{1, 1}, {2, 1}, {2, 2}, {1, 1}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWKT(tt.in)
+			if err != nil {
+				t.Fatalf("ParseWKT(%q): %v", tt.in, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseWKT(%q) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWKTErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"empty string", ""},
+		{"spaces only", "   "},
+		{"linestring empty", "LINESTRING EMPTY"},
+		{"multilinestring empty", "MULTILINESTRING EMPTY"},
+		{"z empty", "LINESTRING Z EMPTY"},
+		{"single point line", "LINESTRING(71.43 51.12)"},
+		{"empty parens", "LINESTRING()"},
+		{"multilinestring with short part", "MULTILINESTRING((71.43 51.12,71.44 51.13),(71.45 51.14))"},
+		{"multilinestring without nesting", "MULTILINESTRING(71.43 51.12,71.44 51.13)"},
+		{"missing closing paren", "LINESTRING(71.43 51.12, 71.44 51.13"},
+		{"trailing garbage", "LINESTRING(71.43 51.12, 71.44 51.13) x"},
+		{"one coordinate", "LINESTRING(71.43, 71.44 51.13)"},
+		{"bad number", "POINT(71.4.3 51.12)"},
+		{"two points in point", "POINT(71.43 51.12, 71.44 51.13)"},
+		{"unsupported type", "MULTIPOLYGON(((0 0,1 0,1 1,0 0)))"},
+		{"srid without separator", "SRID=4326 LINESTRING(0 0,1 1)"},
+		{"polygon not closed", "POLYGON((0 0,4 0,4 4,0 4))"},
+		{"polygon ring too short", "POLYGON((0 0,4 0,0 0))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if g, err := ParseWKT(tt.in); err == nil {
+				t.Errorf("ParseWKT(%q) = %#v, want error", tt.in, g)
+			}
+		})
+	}
+}
+
+func TestWKTRoundTrip(t *testing.T) {
+	for _, in := range []string{
+		"POINT(71.43 51.12)",
+		"LINESTRING(71.43 51.12,71.44 51.13)",
+		"MULTILINESTRING((71.43 51.12,71.44 51.13),(71.45 51.14,71.46 51.15))",
+		"POLYGON((0 0,4 0,4 4,0 4,0 0))",
+	} {
+		g, err := ParseWKT(in)
+		if err != nil {
+			t.Fatalf("ParseWKT(%q): %v", in, err)
+		}
+		if got := g.WKT(); got != in {
+			t.Errorf("WKT() = %q, want %q", got, in)
+		}
+	}
+}
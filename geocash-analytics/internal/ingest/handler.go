@@ -0,0 +1,178 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"geocash/internal/api"
+	"geocash/internal/config"
+)
+
+// Ограничения на размер выдачи истории импортов
+const (
+	defaultRunsLimit = 20
+	maxRunsLimit     = 200
+)
+
+// RunsHandler - GET /api/v1/imports?limit=20
+type RunsHandler struct {
+	runs RunStore
+}
+
+func NewRunsHandler(runs RunStore) *RunsHandler {
+	return &RunsHandler{runs: runs}
+}
+
+func (h *RunsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	limit := defaultRunsLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxRunsLimit {
+			api.WriteError(w, http.StatusBadRequest, errors.New("параметр limit: ожидается число от 1 до 200"))
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.runs.List(r.Context(), limit)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, runs)
+}
+
+// JobsHandler - загрузка файлов трафика и статус фоновых импортов:
+//
+//	POST   /api/v1/imports/traffic?force=true  (multipart/form-data, поле file)
+//	GET    /api/v1/imports/jobs
+//	GET    /api/v1/imports/jobs/{id}
+//	DELETE /api/v1/imports/jobs/{id}
+type JobsHandler struct {
+	jobs          *JobManager
+	dir           string
+	maxBytes      int64
+	uploadTimeout time.Duration
+}
+
+func NewJobsHandler(jobs *JobManager, cfg config.TrafficConfig) *JobsHandler {
+	return &JobsHandler{
+		jobs:          jobs,
+		dir:           cfg.UploadDir,
+		maxBytes:      int64(cfg.MaxUploadMB) << 20,
+		uploadTimeout: cfg.UploadTimeout,
+	}
+}
+
+// Upload сохраняет файл на диск потоком (без буфера в памяти) и ставит импорт в очередь
+func (h *JobsHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	force := false
+	if v := r.URL.Query().Get("force"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			api.WriteError(w, http.StatusBadRequest, errors.New("параметр force: ожидается true или false"))
+			return
+		}
+		force = b
+	}
+
+	// Большой файл не успеет прийти за обычные таймауты сервера
+	// (WriteTimeout тоже отсчитывается от начала запроса)
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Now().Add(h.uploadTimeout))
+	rc.SetWriteDeadline(time.Now().Add(h.uploadTimeout + time.Minute))
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBytes)
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, errors.New("ожидается multipart/form-data с полем file"))
+		return
+	}
+	var part *multipart.Part
+	for {
+		part, err = mr.NextPart()
+		if err == io.EOF {
+			api.WriteError(w, http.StatusBadRequest, errors.New("в запросе нет поля file"))
+			return
+		}
+		if err != nil {
+			api.WriteError(w, http.StatusBadRequest, fmt.Errorf("ошибка чтения запроса: %w", err))
+			return
+		}
+		if part.FormName() == "file" && part.FileName() != "" {
+			break
+		}
+	}
+
+	id := NewJobID()
+	name := filepath.Base(part.FileName())
+	path, err := h.save(part, id+"_"+name)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			api.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("файл больше %d МБ", h.maxBytes>>20))
+			return
+		}
+		api.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	job := h.jobs.Submit(id, name, path, force)
+	api.WriteJSON(w, http.StatusAccepted, job)
+}
+
+// save копирует файл в upload_dir; недокачанный файл удаляется
+func (h *JobsHandler) save(src io.Reader, name string) (string, error) {
+	if err := os.MkdirAll(h.dir, 0o755); err != nil {
+		return "", fmt.Errorf("не удалось создать %s: %w", h.dir, err)
+	}
+	path := filepath.Join(h.dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("не удалось сохранить файл: %w", err)
+	}
+	_, err = io.Copy(f, src)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// List - задачи с момента запуска сервера (старые завершенные забываются)
+func (h *JobsHandler) List(w http.ResponseWriter, r *http.Request) {
+	api.WriteJSON(w, http.StatusOK, h.jobs.List())
+}
+
+// Get - статус, прогресс и итог задачи
+func (h *JobsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Get(r.PathValue("id"))
+	if err != nil {
+		api.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, job)
+}
+
+// Cancel отменяет задачу; итоговый статус CANCELLED появится, когда импорт остановится
+func (h *JobsHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Cancel(r.PathValue("id"))
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		api.WriteError(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrJobFinished):
+		api.WriteError(w, http.StatusConflict, err)
+	default:
+		api.WriteJSON(w, http.StatusAccepted, job)
+	}
+}
@@ -0,0 +1,229 @@
+package ingest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Статусы фоновой задачи импорта
+const (
+	JobQueued    = "QUEUED"
+	JobRunning   = "RUNNING"
+	JobSuccess   = "SUCCESS"
+	JobFailed    = "FAILED"
+	JobCancelled = "CANCELLED"
+	JobSkipped   = "SKIPPED" // файл уже импортирован, а force не задан
+)
+
+// maxFinishedJobs - сколько завершенных задач хранить; более старые забываются
+// (история импортов остается в import_runs)
+const maxFinishedJobs = 100
+
+// ErrJobNotFound - задачи с таким id нет (или сервер перезапускался)
+var ErrJobNotFound = errors.New("задача импорта не найдена")
+
+// ErrJobFinished - задача уже завершена, отменять нечего
+var ErrJobFinished = errors.New("задача импорта уже завершена")
+
+// Job - фоновый импорт загруженного файла
+type Job struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	FileName   string     `json:"fileName"` // имя файла у пользователя
+	Path       string     `json:"path"`     // где файл сохранен на сервере (удаляется после импорта)
+	Force      bool       `json:"force"`
+	Status     string     `json:"status"`
+	Progress   Progress   `json:"progress"`
+	Run        *ImportRun `json:"run,omitempty"` // запись import_runs с итоговой статистикой
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+func (j *Job) finished() bool {
+	return j.FinishedAt != nil
+}
+
+// JobManager запускает импорты в фоне по одному (зоны обновляются в одной транзакции,
+// параллельные импорты только ждали бы блокировок) и хранит их статус в памяти
+type JobManager struct {
+	pipeline *TrafficPipeline
+	timeout  time.Duration
+
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	queue chan struct{} // семафор на один одновременный импорт
+}
+
+func NewJobManager(pipeline *TrafficPipeline, timeout time.Duration) *JobManager {
+	return &JobManager{
+		pipeline: pipeline,
+		timeout:  timeout,
+		jobs:     make(map[string]*Job),
+		queue:    make(chan struct{}, 1),
+	}
+}
+
+// NewJobID - случайный идентификатор задачи
+func NewJobID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Submit ставит импорт уже сохраненного файла в очередь и сразу возвращает задачу
+func (m *JobManager) Submit(id, fileName, path string, force bool) Job {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	job := &Job{
+		ID:        id,
+		Kind:      KindTraffic,
+		FileName:  fileName,
+		Path:      path,
+		Force:     force,
+		Status:    JobQueued,
+		CreatedAt: time.Now(),
+		cancel:    cancel,
+	}
+
+	m.mu.Lock()
+	m.jobs[id] = job
+	snapshot := *job
+	m.mu.Unlock()
+
+	go m.run(ctx, job)
+	return snapshot
+}
+
+func (m *JobManager) run(ctx context.Context, job *Job) {
+	defer job.cancel()
+
+	// Ждем своей очереди (или отмены, пока стоим в ней)
+	select {
+	case m.queue <- struct{}{}:
+		defer func() { <-m.queue }()
+	case <-ctx.Done():
+		m.finish(ctx, job, nil, ctx.Err())
+		return
+	}
+
+	m.update(job, func(j *Job) {
+		now := time.Now()
+		j.Status = JobRunning
+		j.StartedAt = &now
+		j.Progress.Phase = PhaseReading
+	})
+	log.Printf("📥 Задача импорта %s: %s", job.ID, job.FileName)
+
+	run, err := m.pipeline.ImportFile(ctx, job.Path, job.Force, func(p Progress) {
+		m.update(job, func(j *Job) { j.Progress = p })
+	})
+	m.finish(ctx, job, &run, err)
+}
+
+// finish фиксирует итог и удаляет загруженный файл: повторить импорт можно только
+// новой загрузкой, а отчет об отброшенных строках (<файл>.rejects.csv) остается.
+// Отмену проверяем по ctx: драйвер БД возвращает свою ошибку ("canceling statement"),
+// а не context.Canceled.
+func (m *JobManager) finish(ctx context.Context, job *Job, run *ImportRun, err error) {
+	if rmErr := os.Remove(job.Path); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) {
+		log.Printf("⚠️ Не удалось удалить загруженный файл %s: %v", job.Path, rmErr)
+	}
+
+	m.update(job, func(j *Job) {
+		now := time.Now()
+		j.FinishedAt = &now
+		if run != nil && run.ID != 0 {
+			j.Run = run
+		}
+
+		switch {
+		case err == nil:
+			j.Status = JobSuccess
+			j.Progress.Percent = 100
+		case errors.Is(err, ErrAlreadyImported):
+			j.Status = JobSkipped
+			j.Error = err.Error()
+		case errors.Is(ctx.Err(), context.Canceled):
+			j.Status = JobCancelled
+			j.Error = "импорт отменен"
+		default:
+			j.Status = JobFailed
+			j.Error = err.Error()
+		}
+	})
+	log.Printf("🏁 Задача импорта %s: %s %s", job.ID, job.Status, job.Error)
+	m.prune()
+}
+
+// prune забывает самые старые завершенные задачи сверх maxFinishedJobs
+func (m *JobManager) prune() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var done []*Job
+	for _, j := range m.jobs {
+		if j.finished() {
+			done = append(done, j)
+		}
+	}
+	if len(done) <= maxFinishedJobs {
+		return
+	}
+	sort.Slice(done, func(a, b int) bool { return done[a].FinishedAt.Before(*done[b].FinishedAt) })
+	for _, j := range done[:len(done)-maxFinishedJobs] {
+		delete(m.jobs, j.ID)
+	}
+}
+
+func (m *JobManager) update(job *Job, f func(*Job)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f(job)
+}
+
+// Get - снимок состояния задачи
+func (m *JobManager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return *job, nil
+}
+
+// List - задачи с момента запуска сервера (завершенные - последние maxFinishedJobs), новые первыми
+func (m *JobManager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := make([]Job, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		jobs = append(jobs, *j)
+	}
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].CreatedAt.After(jobs[b].CreatedAt) })
+	return jobs
+}
+
+// Cancel отменяет контекст задачи: импорт прерывается, транзакция откатывается
+func (m *JobManager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	if job.finished() {
+		return *job, ErrJobFinished
+	}
+	job.cancel()
+	return *job, nil
+}
@@ -0,0 +1,77 @@
+package ingest
+
+import (
+	"io"
+	"sync/atomic"
+
+	"geocash/internal/domain/traffic"
+	"geocash/internal/platform/loader"
+)
+
+// Как часто (в строках) сообщать о прогрессе
+const progressEvery = 1000
+
+// Этапы импорта
+const (
+	PhaseReading = "reading" // чтение и загрузка сегментов
+	PhaseZones   = "zones"   // пересечение с зонами и пересчет traffic_score
+)
+
+// Progress - текущее состояние импорта файла
+type Progress struct {
+	Phase        string  `json:"phase"`
+	Percent      float64 `json:"percent"` // прочитано байт от размера файла, 0..100
+	RowsRead     int     `json:"rowsRead"`
+	RowsRejected int     `json:"rowsRejected"`
+	RowsAccepted int     `json:"rowsAccepted"` // прошли проверку и отправлены в БД
+}
+
+// countingReader считает прочитанные байты (для процента выполнения)
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// progressSource пропускает сегменты насквозь и периодически сообщает прогресс
+type progressSource struct {
+	src      traffic.SegmentSource
+	reader   *loader.TrafficReader
+	rejects  *rejectLog
+	counter  *countingReader
+	size     int64
+	accepted int
+	report   func(Progress)
+}
+
+func (p *progressSource) Next() (traffic.TrafficSegment, error) {
+	seg, err := p.src.Next()
+	switch {
+	case err == io.EOF:
+		p.notify(PhaseZones)
+	case err == nil:
+		p.accepted++
+		if p.accepted%progressEvery == 0 {
+			p.notify(PhaseReading)
+		}
+	}
+	return seg, err
+}
+
+func (p *progressSource) notify(phase string) {
+	pr := Progress{
+		Phase:        phase,
+		RowsRead:     p.reader.RowsRead(),
+		RowsRejected: p.rejects.count,
+		RowsAccepted: p.accepted,
+	}
+	if p.size > 0 {
+		pr.Percent = min(100, float64(p.counter.n.Load())*100/float64(p.size))
+	}
+	p.report(pr)
+}
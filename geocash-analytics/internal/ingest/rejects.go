@@ -0,0 +1,66 @@
+package ingest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"geocash/internal/domain/traffic"
+)
+
+// RejectsSuffix - отчет об отброшенных строках лежит рядом с входным файлом
+const RejectsSuffix = ".rejects.csv"
+
+// rejectLog пишет отброшенные строки в CSV (line,column,reason).
+// Файл создается при первой ошибке, чтобы чистый импорт не оставлял мусора.
+type rejectLog struct {
+	path  string
+	f     *os.File
+	w     *csv.Writer
+	count int
+	err   error
+}
+
+func newRejectLog(inputPath string) *rejectLog {
+	return &rejectLog{path: inputPath + RejectsSuffix}
+}
+
+func (l *rejectLog) add(r traffic.Reject) {
+	l.count++
+	if l.err != nil {
+		return
+	}
+	if l.w == nil {
+		l.f, l.err = os.Create(l.path)
+		if l.err != nil {
+			// Том может быть только для чтения: импорт не прерываем, ошибки лишь считаем
+			log.Printf("⚠️ Не удалось создать файл отказов %s: %v", l.path, l.err)
+			return
+		}
+		l.w = csv.NewWriter(l.f)
+		l.w.Write([]string{"line", "column", "reason"})
+	}
+	l.w.Write([]string{strconv.Itoa(r.Line), r.Column, r.Reason})
+}
+
+// close дописывает файл и возвращает его путь ("" - файла нет)
+func (l *rejectLog) close() (string, error) {
+	if l.w == nil {
+		// Старый отчет от предыдущего запуска больше не актуален
+		if l.err == nil {
+			os.Remove(l.path)
+		}
+		return "", nil
+	}
+	l.w.Flush()
+	if err := l.w.Error(); err != nil {
+		l.f.Close()
+		return "", fmt.Errorf("ошибка записи %s: %w", l.path, err)
+	}
+	if err := l.f.Close(); err != nil {
+		return "", fmt.Errorf("ошибка записи %s: %w", l.path, err)
+	}
+	return l.path, nil
+}
@@ -0,0 +1,68 @@
+package ingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"geocash/internal/domain/traffic"
+)
+
+// Статусы запуска импорта
+const (
+	RunRunning = "RUNNING"
+	RunSuccess = "SUCCESS"
+	RunFailed  = "FAILED"
+)
+
+// KindTraffic - импорт трафика 2GIS
+const KindTraffic = "traffic"
+
+// ErrAlreadyImported - файл с таким содержимым уже успешно загружен
+var ErrAlreadyImported = errors.New("файл уже импортирован")
+
+// ImportRun - запись в import_runs
+type ImportRun struct {
+	ID          int64               `json:"id"`
+	Kind        string              `json:"kind"`
+	FilePath    string              `json:"filePath"`
+	ContentHash string              `json:"contentHash"`
+	Status      string              `json:"status"`
+	Forced      bool                `json:"forced"`
+	Stats       traffic.ImportStats `json:"stats"`
+	Error       string              `json:"error,omitempty"`
+	StartedAt   time.Time           `json:"startedAt"`
+	FinishedAt  *time.Time          `json:"finishedAt,omitempty"`
+}
+
+// RunStore хранит историю импортов (реализация: postgres.ImportRunRepository)
+type RunStore interface {
+	// FindApplied - последний успешный импорт с таким хэшем (nil, если не было)
+	FindApplied(ctx context.Context, kind, hash string) (*ImportRun, error)
+	// Start создает запись RUNNING и заполняет ID и StartedAt
+	Start(ctx context.Context, run *ImportRun) error
+	// Finish сохраняет итог: статус, статистику, ошибку и время окончания
+	Finish(ctx context.Context, run *ImportRun) error
+	// List - последние запуски, новые первыми
+	List(ctx context.Context, limit int) ([]ImportRun, error)
+}
+
+// hashFile - SHA-256 содержимого файла
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("не удалось открыть файл: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("ошибка чтения %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
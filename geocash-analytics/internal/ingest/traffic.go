@@ -0,0 +1,117 @@
+// Package ingest - конвейер импорта трафика: чтение CSV, проверка строк,
+// отчет об отказах и загрузка в хранилище зон
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"geocash/internal/config"
+	"geocash/internal/domain/traffic"
+	"geocash/internal/geo"
+	"geocash/internal/platform/loader"
+)
+
+// TrafficImporter загружает поток сегментов в хранилище зон
+// (реализация: postgres.TrafficIntegrator)
+type TrafficImporter interface {
+	// ImportTraffic заменяет трафик зон в тех из areas (границы городов), которых
+	// коснулся хоть один сегмент
+	ImportTraffic(ctx context.Context, src traffic.SegmentSource, areas []geo.Bounds) (traffic.ImportStats, error)
+}
+
+// TrafficPipeline - чтение CSV -> проверка -> загрузка, с записью в историю импортов
+type TrafficPipeline struct {
+	importer TrafficImporter
+	runs     RunStore
+	cfg      config.TrafficConfig
+	areas    []geo.Bounds
+}
+
+// NewTrafficPipeline: areas - границы городов, сегменты вне их всех отбрасываются
+// (один файл может покрывать несколько городов)
+func NewTrafficPipeline(importer TrafficImporter, runs RunStore, cfg config.TrafficConfig, areas []geo.Bounds) *TrafficPipeline {
+	return &TrafficPipeline{importer: importer, runs: runs, cfg: cfg, areas: areas}
+}
+
+// ImportFile импортирует файл и записывает запуск в import_runs.
+// Файл, чье содержимое уже успешно загружалось, пропускается с
+// ErrAlreadyImported, если не задан force. Файл при этом не трогается,
+// поэтому импорт работает и на томах только для чтения.
+// onProgress (может быть nil) вызывается по ходу чтения файла.
+func (p *TrafficPipeline) ImportFile(ctx context.Context, path string, force bool, onProgress func(Progress)) (ImportRun, error) {
+	hash, err := hashFile(path)
+	if err != nil {
+		return ImportRun{}, err
+	}
+
+	if !force {
+		prev, err := p.runs.FindApplied(ctx, KindTraffic, hash)
+		if err != nil {
+			return ImportRun{}, err
+		}
+		if prev != nil {
+			return *prev, fmt.Errorf("%w: запуск #%d от %s", ErrAlreadyImported, prev.ID, prev.StartedAt.Format("2006-01-02 15:04"))
+		}
+	}
+
+	run := ImportRun{Kind: KindTraffic, FilePath: path, ContentHash: hash, Status: RunRunning, Forced: force}
+	if err := p.runs.Start(ctx, &run); err != nil {
+		return run, err
+	}
+
+	run.Stats, err = p.importFile(ctx, path, onProgress)
+	run.Status = RunSuccess
+	if err != nil {
+		run.Status = RunFailed
+		run.Error = err.Error()
+	}
+
+	// Итог записываем даже если ctx уже отменен
+	finishCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if ferr := p.runs.Finish(finishCtx, &run); ferr != nil && err == nil {
+		err = ferr
+	}
+	return run, err
+}
+
+// importFile - сам импорт: отброшенные строки пишутся в path + RejectsSuffix,
+// статистика возвращается и при ошибке
+func (p *TrafficPipeline) importFile(ctx context.Context, path string, onProgress func(Progress)) (traffic.ImportStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return traffic.ImportStats{}, fmt.Errorf("не удалось открыть файл: %w", err)
+	}
+	defer f.Close()
+
+	counter := &countingReader{r: f}
+	reader, err := loader.NewTrafficReader(counter, p.cfg.Schema)
+	if err != nil {
+		return traffic.ImportStats{}, err
+	}
+
+	rejects := newRejectLog(path)
+	reader.OnReject = rejects.add
+	var src traffic.SegmentSource = newValidator(reader, p.areas, p.cfg.MaxRejectRate, rejects.add)
+	if onProgress != nil {
+		var size int64
+		if fi, err := f.Stat(); err == nil {
+			size = fi.Size()
+		}
+		src = &progressSource{src: src, reader: reader, rejects: rejects, counter: counter, size: size, report: onProgress}
+	}
+
+	stats, err := p.importer.ImportTraffic(ctx, src, p.areas)
+	stats.SegmentsRead = reader.RowsRead()
+	stats.SegmentsRejected = rejects.count
+
+	rejectsFile, closeErr := rejects.close()
+	stats.RejectsFile = rejectsFile
+	if err != nil {
+		return stats, err
+	}
+	return stats, closeErr
+}
@@ -0,0 +1,124 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"geocash/internal/domain/traffic"
+	"geocash/internal/geo"
+	"geocash/internal/platform/loader"
+)
+
+// ErrTooManyRejects - доля отброшенных строк выше порога, импорт отменяется
+var ErrTooManyRejects = errors.New("слишком много отброшенных строк")
+
+// validator - стадия проверки между чтением CSV и загрузкой в БД.
+// Проверяет WKT, попадание в границы одного из городов и дубликаты edge_id
+// (кроме приблизительных ID из экспоненциальной записи: их совпадение ничего не значит).
+// Реализует traffic.SegmentSource.
+type validator struct {
+	reader  *loader.TrafficReader
+	areas   []geo.Bounds // bbox городов
+	maxRate float64
+	reject  func(traffic.Reject)
+
+	seen     map[int64]int // edge_id -> строка первого вхождения
+	rejected int
+	approx   int // сегментов с приблизительным edge_id
+}
+
+func newValidator(reader *loader.TrafficReader, areas []geo.Bounds, maxRate float64, reject func(traffic.Reject)) *validator {
+	return &validator{
+		reader:  reader,
+		areas:   areas,
+		maxRate: maxRate,
+		reject:  reject,
+		seen:    make(map[int64]int),
+	}
+}
+
+// Next отдает только прошедшие проверку сегменты. В конце файла сверяет
+// долю отказов с порогом: при превышении вместо io.EOF возвращается
+// ErrTooManyRejects, и загрузка откатывается.
+func (v *validator) Next() (traffic.TrafficSegment, error) {
+	for {
+		seg, err := v.reader.Next()
+		if err == io.EOF {
+			return seg, v.finish()
+		}
+		if err != nil {
+			return seg, err
+		}
+
+		if reason, column := v.check(seg); reason != "" {
+			v.rejected++
+			v.reject(traffic.Reject{Line: seg.Line, Column: column, Reason: reason})
+			continue
+		}
+		if seg.EdgeIDApprox {
+			v.approx++
+		} else {
+			v.seen[seg.EdgeID] = seg.Line
+		}
+		return seg, nil
+	}
+}
+
+func (v *validator) check(seg traffic.TrafficSegment) (reason, column string) {
+	if first, dup := v.seen[seg.EdgeID]; dup && !seg.EdgeIDApprox {
+		return fmt.Sprintf("дубликат edge_id %d (первое вхождение в строке %d)", seg.EdgeID, first), "edge_id"
+	}
+
+	g, err := geo.ParseWKT(seg.Geometry)
+	if err != nil {
+		return "некорректный WKT: " + err.Error(), "geometry"
+	}
+	switch g.(type) {
+	case geo.LineString, geo.MultiLineString:
+	case geo.Point:
+		return "ожидается линия, получена точка", "geometry"
+	default:
+		return "ожидается линия, получен полигон", "geometry"
+	}
+
+	b := g.Bounds()
+	if !b.Valid() {
+		return "координаты вне диапазона WGS84", "geometry"
+	}
+	if !v.inArea(b) {
+		swapped := geo.Bounds{MinLng: b.MinLat, MinLat: b.MinLng, MaxLng: b.MaxLat, MaxLat: b.MaxLng}
+		if v.inArea(swapped) {
+			return "сегмент вне границ городов: похоже, перепутаны широта и долгота", "geometry"
+		}
+		return "сегмент вне границ городов", "geometry"
+	}
+	return "", ""
+}
+
+// inArea - пересекает ли b bbox хотя бы одного города
+func (v *validator) inArea(b geo.Bounds) bool {
+	for _, area := range v.areas {
+		if b.Intersects(area) {
+			return true
+		}
+	}
+	return false
+}
+
+// finish проверяет долю отказов по всему файлу
+func (v *validator) finish() error {
+	if v.approx > 0 {
+		fmt.Printf("⚠️ edge_id в экспоненциальной записи у %d сегментов: ID приблизительные, дубликаты не проверялись\n", v.approx)
+	}
+	read := v.reader.RowsRead()
+	if read == 0 {
+		return io.EOF
+	}
+	rejected := v.reader.Rejected() + v.rejected
+	rate := float64(rejected) / float64(read)
+	if rate > v.maxRate {
+		return fmt.Errorf("%w: %d из %d (%.1f%%), порог %.1f%%", ErrTooManyRejects, rejected, read, rate*100, v.maxRate*100)
+	}
+	return io.EOF
+}
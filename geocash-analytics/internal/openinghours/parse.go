@@ -0,0 +1,216 @@
+// Package openinghours - разбор и вычисление тега OSM opening_hours
+// (https://wiki.openstreetmap.org/wiki/Key:opening_hours) в объеме, который
+// встречается у банкоматов: дни недели и диапазоны, несколько интервалов времени,
+// работа за полночь, 24/7, off и праздничные дни PH. Месяцы, даты, недели,
+// школьные каникулы и восход/закат не поддерживаются - Parse вернет ошибку.
+package openinghours
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+// Status - состояние в момент времени
+type Status string
+
+const (
+	Open    Status = "open"
+	Closed  Status = "closed"
+	Unknown Status = "unknown" // правило unknown или режим не удалось разобрать
+)
+
+// span - интервал [from, to) в минутах от начала дня; to > 24:00 - работа за полночь
+type span struct {
+	from, to int
+}
+
+// rule - одно правило между ";": к каким дням применяется и что в эти дни
+type rule struct {
+	days    [7]bool // по time.Weekday
+	holiday bool    // PH
+	ranges  []span
+	status  Status
+}
+
+// Schedule - разобранный opening_hours
+type Schedule struct {
+	rules []rule
+}
+
+var weekdays = map[string]time.Weekday{
+	"Su": time.Sunday, "Mo": time.Monday, "Tu": time.Tuesday, "We": time.Wednesday,
+	"Th": time.Thursday, "Fr": time.Friday, "Sa": time.Saturday,
+}
+
+var (
+	comments   = regexp.MustCompile(`"[^"]*"`)
+	separators = regexp.MustCompile(`\s*([,-])\s*`)
+	// Запятая после времени перед днями - дополнительное правило:
+	// "Mo-Fr 10:00-20:00, Sa 10:00-16:00". Дни таких правил обычно не пересекаются,
+	// поэтому они разбираются как правила через ";"
+	additional = regexp.MustCompile(`(\d|off|closed)\s*,\s*((?:Mo|Tu|We|Th|Fr|Sa|Su|PH)\b)`)
+)
+
+// Parse разбирает значение тега opening_hours
+func Parse(value string) (*Schedule, error) {
+	value = strings.TrimSpace(comments.ReplaceAllString(value, ""))
+	if value == "" {
+		return nil, errors.New("пустой режим работы")
+	}
+	if strings.Contains(value, "||") {
+		return nil, errors.New("резервные правила || не поддерживаются")
+	}
+
+	value = additional.ReplaceAllString(value, "$1;$2")
+
+	var s Schedule
+	for _, text := range strings.Split(value, ";") {
+		text = strings.TrimSpace(separators.ReplaceAllString(text, "$1"))
+		if text == "" {
+			continue
+		}
+		r, err := parseRule(text)
+		if err != nil {
+			return nil, fmt.Errorf("правило %q: %w", text, err)
+		}
+		s.rules = append(s.rules, r)
+	}
+	if len(s.rules) == 0 {
+		return nil, errors.New("пустой режим работы")
+	}
+	return &s, nil
+}
+
+func parseRule(text string) (rule, error) {
+	r := rule{status: Open}
+	fields := strings.Fields(text)
+
+	// Дни: Mo-Fr,Su,PH; без дней - каждый день
+	if len(fields) > 0 && isDaySelector(fields[0]) {
+		if err := r.parseDays(fields[0]); err != nil {
+			return r, err
+		}
+		fields = fields[1:]
+	} else {
+		for d := range r.days {
+			r.days[d] = true
+		}
+	}
+
+	// Время: 10:00-14:00,15:00-20:00 или 24/7; без времени - весь день
+	if len(fields) > 0 && (fields[0] == "24/7" || strings.Contains(fields[0], ":")) {
+		if err := r.parseTimes(fields[0]); err != nil {
+			return r, err
+		}
+		fields = fields[1:]
+	} else {
+		r.ranges = []span{{0, minutesPerDay}}
+	}
+
+	// Модификатор: open, off/closed, unknown
+	if len(fields) > 0 {
+		switch strings.ToLower(fields[0]) {
+		case "open":
+		case "off", "closed":
+			r.status, r.ranges = Closed, nil
+		case "unknown":
+			r.status, r.ranges = Unknown, nil
+		default:
+			return r, fmt.Errorf("не поддерживается %q", fields[0])
+		}
+		fields = fields[1:]
+	}
+	if len(fields) > 0 {
+		return r, fmt.Errorf("не поддерживается %q", strings.Join(fields, " "))
+	}
+	return r, nil
+}
+
+// isDaySelector - поле похоже на перечень дней (а не на время или месяц)
+func isDaySelector(field string) bool {
+	for _, part := range strings.Split(field, ",") {
+		from, _, _ := strings.Cut(part, "-")
+		if _, ok := weekdays[from]; !ok && from != "PH" {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *rule) parseDays(field string) error {
+	for _, part := range strings.Split(field, ",") {
+		if part == "PH" {
+			r.holiday = true
+			continue
+		}
+		fromName, toName, isRange := strings.Cut(part, "-")
+		from, ok := weekdays[fromName]
+		if !ok {
+			return fmt.Errorf("неизвестный день %q", fromName)
+		}
+		to := from
+		if isRange {
+			if to, ok = weekdays[toName]; !ok {
+				return fmt.Errorf("неизвестный день %q", toName)
+			}
+		}
+		// Fr-Mo - через воскресенье
+		for d := from; ; d = (d + 1) % 7 {
+			r.days[d] = true
+			if d == to {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (r *rule) parseTimes(field string) error {
+	if field == "24/7" {
+		r.ranges = []span{{0, minutesPerDay}}
+		return nil
+	}
+	for _, part := range strings.Split(field, ",") {
+		fromText, toText, ok := strings.Cut(part, "-")
+		if !ok {
+			return fmt.Errorf("ожидается интервал вида 10:00-22:00, получено %q", part)
+		}
+		from, err := parseClock(fromText, minutesPerDay)
+		if err != nil {
+			return err
+		}
+		to, err := parseClock(toText, 2*minutesPerDay)
+		if err != nil {
+			return err
+		}
+		if to <= from {
+			to += minutesPerDay // 22:00-02:00 - за полночь
+		}
+		if to-from > minutesPerDay {
+			return fmt.Errorf("интервал %q длиннее суток", part)
+		}
+		r.ranges = append(r.ranges, span{from, to})
+	}
+	return nil
+}
+
+// parseClock - "HH:MM" в минутах, не больше maxMinutes
+func parseClock(text string, maxMinutes int) (int, error) {
+	h, m, ok := strings.Cut(text, ":")
+	hours, err1 := strconv.Atoi(h)
+	minutes, err2 := strconv.Atoi(m)
+	if !ok || err1 != nil || err2 != nil || len(m) != 2 || hours < 0 || minutes < 0 || minutes > 59 {
+		return 0, fmt.Errorf("ожидается время ЧЧ:ММ, получено %q", text)
+	}
+	total := hours*60 + minutes
+	if total > maxMinutes {
+		return 0, fmt.Errorf("время %q вне суток", text)
+	}
+	return total, nil
+}
@@ -0,0 +1,128 @@
+package openinghours
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Calendar - праздничные дни для правил PH
+type Calendar interface {
+	IsHoliday(date time.Time) bool
+}
+
+// Status - работает ли объект в момент t (t должен быть в часовом поясе объекта).
+// Для дня действует последнее подходящее правило; интервалы за полночь
+// продолжаются в следующем дне, если на него нет правила off.
+func (s *Schedule) Status(t time.Time, cal Calendar) Status {
+	minute := t.Hour()*60 + t.Minute()
+
+	if today := s.ruleFor(t, cal); today != nil {
+		switch {
+		case today.status != Open:
+			return today.status
+		case today.covers(minute):
+			return Open
+		}
+	}
+	if yesterday := s.ruleFor(t.AddDate(0, 0, -1), cal); yesterday != nil && yesterday.status == Open &&
+		yesterday.covers(minute+minutesPerDay) {
+		return Open
+	}
+	return Closed
+}
+
+// AlwaysOpen - круглосуточно в любой день, включая праздники
+func (s *Schedule) AlwaysOpen() bool {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		for _, holiday := range []bool{false, true} {
+			r := s.ruleForDay(d, holiday)
+			if r == nil || r.status != Open || !r.coversDay() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *Schedule) ruleFor(date time.Time, cal Calendar) *rule {
+	return s.ruleForDay(date.Weekday(), cal != nil && cal.IsHoliday(date))
+}
+
+// ruleForDay - последнее правило, подходящее дню: более поздние правила перекрывают ранние
+func (s *Schedule) ruleForDay(d time.Weekday, holiday bool) *rule {
+	for i := len(s.rules) - 1; i >= 0; i-- {
+		r := &s.rules[i]
+		if r.days[d] || r.holiday && holiday {
+			return r
+		}
+	}
+	return nil
+}
+
+func (r *rule) covers(minute int) bool {
+	for _, sp := range r.ranges {
+		if sp.from <= minute && minute < sp.to {
+			return true
+		}
+	}
+	return false
+}
+
+// coversDay - интервалы без разрывов покрывают 00:00-24:00
+func (r *rule) coversDay() bool {
+	ranges := append([]span(nil), r.ranges...)
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].from < ranges[j].from })
+	end := 0
+	for _, sp := range ranges {
+		if sp.from > end {
+			return false
+		}
+		end = max(end, sp.to)
+	}
+	return end >= minutesPerDay
+}
+
+// Holidays - праздники: ежегодные (месяц и день) и разовые даты
+type Holidays struct {
+	fixed map[[2]int]bool
+	dates map[string]bool
+}
+
+// NewHolidays: fixed - "ММ-ДД" каждый год, dates - "ГГГГ-ММ-ДД"
+func NewHolidays(fixed, dates []string) (*Holidays, error) {
+	h := &Holidays{fixed: make(map[[2]int]bool), dates: make(map[string]bool)}
+	for _, v := range fixed {
+		t, err := time.Parse("01-02", v)
+		if err != nil {
+			return nil, fmt.Errorf("праздник %q: ожидается ММ-ДД", v)
+		}
+		h.fixed[[2]int{int(t.Month()), t.Day()}] = true
+	}
+	for _, v := range dates {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return nil, fmt.Errorf("праздник %q: ожидается ГГГГ-ММ-ДД", v)
+		}
+		h.dates[t.Format(time.DateOnly)] = true
+	}
+	return h, nil
+}
+
+func (h *Holidays) IsHoliday(date time.Time) bool {
+	return h.fixed[[2]int{int(date.Month()), date.Day()}] || h.dates[date.Format(time.DateOnly)]
+}
+
+// ParseAt разбирает момент времени из запроса: RFC3339 или местное время
+// "2026-10-18T23:00" в часовом поясе loc
+func ParseAt(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(loc), nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("параметр at: ожидается 2026-10-18T23:00 или RFC3339, получено %q", value)
+}
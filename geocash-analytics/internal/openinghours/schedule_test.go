@@ -0,0 +1,189 @@
+package openinghours
+
+import (
+	"testing"
+	"time"
+)
+
+// Неделя 12-18 октября 2026: 12 - понедельник, 18 - воскресенье
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+}
+
+// holidays - праздник 14 октября (среда)
+var holidays = mustHolidays(nil, []string{"2026-10-14"})
+
+func mustHolidays(fixed, dates []string) *Holidays {
+	h, err := NewHolidays(fixed, dates)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		at    time.Time
+		want  Status
+	}{
+		// "additional": запятая перед днями - отдельное правило
+		{"additional weekday open", "Mo-Fr 09:00-18:00, Sa 10:00-14:00", at(12, 10, 0), Open},
+		{"additional weekday closed", "Mo-Fr 09:00-18:00, Sa 10:00-14:00", at(12, 18, 0), Closed},
+		{"additional saturday open", "Mo-Fr 09:00-18:00, Sa 10:00-14:00", at(17, 13, 59), Open},
+		{"additional saturday closed", "Mo-Fr 09:00-18:00, Sa 10:00-14:00", at(17, 15, 0), Closed},
+		{"additional sunday", "Mo-Fr 09:00-18:00, Sa 10:00-14:00", at(18, 12, 0), Closed},
+		{"additional with spaces", "Mo - Fr 09:00 - 18:00 , Sa 10:00-14:00", at(17, 11, 0), Open},
+
+		// Fr-Mo - через воскресенье
+		{"wraparound friday", "Fr-Mo 10:00-20:00", at(16, 12, 0), Open},
+		{"wraparound sunday", "Fr-Mo 10:00-20:00", at(18, 12, 0), Open},
+		{"wraparound monday", "Fr-Mo 10:00-20:00", at(12, 12, 0), Open},
+		{"wraparound tuesday", "Fr-Mo 10:00-20:00", at(13, 12, 0), Closed},
+		{"wraparound thursday", "Fr-Mo 10:00-20:00", at(15, 12, 0), Closed},
+
+		// За полночь
+		{"overnight evening", "22:00-02:00", at(13, 23, 0), Open},
+		{"overnight after midnight", "22:00-02:00", at(13, 1, 30), Open},
+		{"overnight end", "22:00-02:00", at(13, 2, 0), Closed},
+		{"overnight day", "22:00-02:00", at(13, 12, 0), Closed},
+		{"overnight spills from friday", "Fr 22:00-02:00", at(17, 1, 0), Open},
+		{"overnight not from thursday", "Fr 22:00-02:00", at(16, 1, 0), Closed},
+		{"overnight into off day", "Mo-Fr 22:00-02:00; Sa off", at(17, 1, 0), Closed},
+		{"overnight into open day", "Mo-Fr 22:00-02:00; Sa 10:00-14:00", at(17, 1, 0), Open},
+		{"overnight ends at 26:00", "Sa 20:00-26:00", at(18, 1, 59), Open},
+
+		// Праздники
+		{"PH off overrides weekday", "Mo-Fr 09:00-18:00; PH off", at(14, 10, 0), Closed},
+		{"PH off regular day", "Mo-Fr 09:00-18:00; PH off", at(13, 10, 0), Open},
+		{"PH hours", "Mo-Fr 09:00-18:00; PH 10:00-14:00", at(14, 15, 0), Closed},
+		{"PH additional", "Mo-Fr 09:00-18:00, PH off", at(14, 10, 0), Closed},
+		{"earlier PH rule overridden", "PH off; Mo-Fr 09:00-18:00", at(14, 10, 0), Open},
+
+		// 24/7 и прочее
+		{"24/7", "24/7", at(18, 3, 0), Open},
+		{"24/7 holiday", "24/7", at(14, 3, 0), Open},
+		{"24/7 with PH off", "24/7; PH off", at(14, 3, 0), Closed},
+		{"days without time", "Mo-Sa", at(17, 23, 59), Open},
+		{"multiple intervals break", "Mo-Fr 09:00-13:00,14:00-18:00", at(12, 13, 30), Closed},
+		{"multiple intervals second", "Mo-Fr 09:00-13:00,14:00-18:00", at(12, 14, 0), Open},
+		{"later rule wins", "Mo-Su 09:00-18:00; We off", at(14, 10, 0), Closed},
+		{"closed keyword", "Mo-Fr 09:00-18:00; Sa,Su closed", at(18, 10, 0), Closed},
+		{"unknown", "Mo-Fr 09:00-18:00; Sa unknown", at(17, 10, 0), Unknown},
+		{"comment ignored", `Mo-Fr 09:00-18:00 "по записи"`, at(12, 10, 0), Open},
+		{"no rule for day", "Mo 09:00-18:00", at(13, 10, 0), Closed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.value)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.value, err)
+			}
+			if got := s.Status(tt.at, holidays); got != tt.want {
+				t.Errorf("Parse(%q).Status(%s) = %s, want %s", tt.value, tt.at.Format("Mon 02 15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusWithoutCalendar(t *testing.T) {
+	s, err := Parse("Mo-Fr 09:00-18:00; PH off")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Без календаря праздников нет: PH не применяется
+	if got := s.Status(at(14, 10, 0), nil); got != Open {
+		t.Errorf("Status without calendar = %s, want %s", got, Open)
+	}
+}
+
+func TestAlwaysOpen(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"24/7", true},
+		{"00:00-24:00", true},
+		{"Mo-Su 00:00-24:00", true},
+		{"Mo-Su 00:00-12:00,12:00-24:00", true},
+		{"Mo-Su", true},
+		{"24/7; PH off", false},
+		{"Mo-Sa 00:00-24:00", false},
+		{"Mo-Su 00:00-23:59", false},
+		{"Mo-Su 00:00-12:00,12:30-24:00", false},
+		{"Mo-Fr 09:00-18:00, Sa 10:00-14:00", false},
+		{"24/7; Su unknown", false},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.value)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.value, err)
+		}
+		if got := s.AlwaysOpen(); got != tt.want {
+			t.Errorf("Parse(%q).AlwaysOpen() = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"   ",
+		`"только комментарий"`,
+		";",
+		// Резервные правила
+		"Mo-Fr 09:00-18:00 || PH off",
+		"24/7 || unknown",
+		// Месяцы и даты
+		"Jan-Mar 09:00-18:00",
+		"Dec 25 off",
+		"2026 Dec 31 10:00-14:00",
+		"Mo-Fr 09:00-18:00; Dec 24-Dec 31 off",
+		"May-Sep Mo-Fr 09:00-20:00",
+		// Недели, n-й день, восход/закат
+		"week 01-10 Mo-Fr 09:00-18:00",
+		"Mo[1] 09:00-18:00",
+		"sunrise-sunset",
+		"SH off",
+		// Некорректное время
+		"Mo-Fr 9-18",
+		"Mo-Fr 09:00",
+		"Mo-Fr 09:00-18:0",
+		"Mo-Fr 09:60-18:00",
+		"Mo-Fr 25:00-26:00",
+		"Mo-Fr 09:00-49:00",
+		"Mo-Xx 09:00-18:00",
+		"Mo-Fr 09:00-18:00 open now",
+	} {
+		if s, err := Parse(value); err == nil {
+			t.Errorf("Parse(%q) = %+v, want error", value, s)
+		}
+	}
+}
+
+func TestHolidays(t *testing.T) {
+	h := mustHolidays([]string{"12-16"}, []string{"2026-03-21"})
+	tests := []struct {
+		date time.Time
+		want bool
+	}{
+		{time.Date(2026, time.December, 16, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2030, time.December, 16, 23, 0, 0, 0, time.UTC), true},
+		{time.Date(2026, time.March, 21, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2027, time.March, 21, 0, 0, 0, 0, time.UTC), false},
+		{time.Date(2026, time.December, 17, 0, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		if got := h.IsHoliday(tt.date); got != tt.want {
+			t.Errorf("IsHoliday(%s) = %v, want %v", tt.date.Format(time.DateOnly), got, tt.want)
+		}
+	}
+
+	if _, err := NewHolidays([]string{"16.12"}, nil); err == nil {
+		t.Error("NewHolidays with bad fixed date: want error")
+	}
+	if _, err := NewHolidays(nil, []string{"2026-13-01"}); err == nil {
+		t.Error("NewHolidays with bad date: want error")
+	}
+}
@@ -0,0 +1,66 @@
+// Package filestore - хранение данных сервиса в локальных файлах (без БД)
+package filestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"geocash/internal/domain/terminal"
+)
+
+// DatasetStore - наборы банкоматов в JSON, по файлу на город: <dir>/<city>.json
+type DatasetStore struct {
+	dir string
+}
+
+func NewDatasetStore(dir string) *DatasetStore {
+	return &DatasetStore{dir: dir}
+}
+
+// SaveDataset пишет набор во временный файл и переименовывает его:
+// при падении посреди записи остается прежний файл, а не обрезанный
+func (s *DatasetStore) SaveDataset(ctx context.Context, d terminal.Dataset) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %w", s.dir, err)
+	}
+	f, err := os.CreateTemp(s.dir, d.CityID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("ошибка записи набора %s: %w", d.CityID, err)
+	}
+	defer os.Remove(f.Name()) // после Rename файла уже нет
+
+	if err := json.NewEncoder(f).Encode(d); err != nil {
+		f.Close()
+		return fmt.Errorf("ошибка записи набора %s: %w", d.CityID, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("ошибка записи набора %s: %w", d.CityID, err)
+	}
+	if err := os.Rename(f.Name(), s.path(d.CityID)); err != nil {
+		return fmt.Errorf("ошибка записи набора %s: %w", d.CityID, err)
+	}
+	return nil
+}
+
+func (s *DatasetStore) LoadDataset(ctx context.Context, cityID string) (terminal.Dataset, error) {
+	var d terminal.Dataset
+	data, err := os.ReadFile(s.path(cityID))
+	if errors.Is(err, os.ErrNotExist) {
+		return d, terminal.ErrNotFound
+	}
+	if err != nil {
+		return d, fmt.Errorf("ошибка чтения набора %s: %w", cityID, err)
+	}
+	if d, err = terminal.DecodeDataset(data); err != nil {
+		return d, fmt.Errorf("%s: %w", s.path(cityID), err)
+	}
+	return d, nil
+}
+
+func (s *DatasetStore) path(cityID string) string {
+	return filepath.Join(s.dir, cityID+".json")
+}
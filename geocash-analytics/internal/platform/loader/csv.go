@@ -3,54 +3,298 @@ package loader
 import (
 	"encoding/csv"
 	"fmt"
-	"geocash/internal/domain/traffic" // Убедись, что этот пакет есть (entity.go)
+	"geocash/internal/config"
+	"geocash/internal/domain/traffic"
+	"geocash/internal/geo"
 	"io"
-	"os"
+	"math"
 	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
 )
 
-// LoadTrafficCSV читает файл и возвращает массив структур
-func LoadTrafficCSV(path string) ([]traffic.TrafficSegment, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("не удалось открыть файл: %w", err)
+// TrafficReader читает CSV построчно, не загружая файл в память целиком.
+// Колонки определяются по заголовку согласно config.TrafficSchema.
+// Реализует traffic.SegmentSource.
+type TrafficReader struct {
+	r      *csv.Reader
+	schema config.TrafficSchema
+
+	// OnReject вызывается для каждой отброшенной строки (может быть nil)
+	OnReject func(traffic.Reject)
+
+	colEdgeID  int
+	colWeekday int
+	colGeom    int
+	// Необязательные колонки, -1 - нет в файле
+	colWeekend       int
+	colWeekdayHourly int
+	colWeekendHourly int
+
+	read     int
+	rejected int
+}
+
+// NewTrafficReader читает заголовок, находит нужные колонки и готовит поток сегментов
+func NewTrafficReader(src io.Reader, schema config.TrafficSchema) (*TrafficReader, error) {
+	if schema.Encoding == config.EncodingWindows1251 {
+		src = charmap.Windows1251.NewDecoder().Reader(src)
 	}
-	defer f.Close()
 
-	r := csv.NewReader(f)
-	// r.Comma = ';' // Если вдруг разделитель точка с запятой
+	r := csv.NewReader(src)
+	r.Comma, _ = utf8.DecodeRuneInString(schema.Delimiter)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать заголовок: %w", err)
+	}
+	// ReuseRecord: копируем заголовок, следующий Read перезапишет срез
+	header = append([]string(nil), header...)
 
-	// Пропускаем заголовок
-	if _, err := r.Read(); err != nil {
-		return nil, err
+	t := &TrafficReader{r: r, schema: schema}
+	cols := []struct {
+		name  string
+		alias []string
+		dst   *int
+	}{
+		{"edge_id", schema.Columns.EdgeID, &t.colEdgeID},
+		{"weekday_traffic", schema.Columns.WeekdayTraffic, &t.colWeekday},
+		{"geometry", schema.Columns.Geometry, &t.colGeom},
+	}
+	for _, c := range cols {
+		idx, err := findColumn(header, c.name, c.alias)
+		if err != nil {
+			return nil, err
+		}
+		*c.dst = idx
 	}
 
-	var segments []traffic.TrafficSegment
+	optional := []struct {
+		alias []string
+		dst   *int
+	}{
+		{schema.Columns.WeekendTraffic, &t.colWeekend},
+		{schema.Columns.WeekdayHourly, &t.colWeekdayHourly},
+		{schema.Columns.WeekendHourly, &t.colWeekendHourly},
+	}
+	for _, c := range optional {
+		*c.dst = lookupColumn(header, c.alias)
+	}
+	return t, nil
+}
 
+// Next возвращает следующий корректный сегмент или io.EOF.
+// Битые строки пропускаются, учитываются в Rejected и передаются в OnReject.
+func (t *TrafficReader) Next() (traffic.TrafficSegment, error) {
 	for {
-		record, err := r.Read()
+		record, err := t.r.Read()
 		if err == io.EOF {
-			break
+			return traffic.TrafficSegment{}, io.EOF
 		}
+		t.read++
 		if err != nil {
-			return nil, err
+			if pe, ok := err.(*csv.ParseError); ok {
+				t.reject(traffic.Reject{Line: pe.StartLine, Reason: pe.Err.Error()})
+				continue
+			}
+			return traffic.TrafficSegment{}, err
 		}
 
-		// Парсим ID из научной нотации (1.91E+16)
-		edgeIDFloat, err := strconv.ParseFloat(record[0], 64)
-		if err != nil {
+		line, _ := t.r.FieldPos(0)
+		seg, rej := t.parse(record)
+		if rej != nil {
+			rej.Line = line
+			t.reject(*rej)
 			continue // Пропускаем битые строки
 		}
+		seg.Line = line
+		return seg, nil
+	}
+}
+
+func (t *TrafficReader) reject(r traffic.Reject) {
+	t.rejected++
+	if t.OnReject != nil {
+		t.OnReject(r)
+	}
+}
+
+// parse разбирает строку; при ошибке возвращает причину отказа
+func (t *TrafficReader) parse(record []string) (traffic.TrafficSegment, *traffic.Reject) {
+	if len(record) <= max(t.colEdgeID, t.colWeekday, t.colGeom, t.colWeekend, t.colWeekdayHourly, t.colWeekendHourly) {
+		return traffic.TrafficSegment{}, &traffic.Reject{Reason: fmt.Sprintf("слишком мало колонок: %d", len(record))}
+	}
+
+	edgeID, approx, rej := t.edgeID(record[t.colEdgeID])
+	if rej != nil {
+		return traffic.TrafficSegment{}, rej
+	}
+
+	wd, rej := t.count(record[t.colWeekday], "weekday_traffic")
+	if rej != nil {
+		return traffic.TrafficSegment{}, rej
+	}
+	// Без колонки (или значения) выходного трафика считаем его равным будничному
+	we := wd
+	if t.colWeekend >= 0 && strings.TrimSpace(record[t.colWeekend]) != "" {
+		if we, rej = t.count(record[t.colWeekend], "weekend_traffic"); rej != nil {
+			return traffic.TrafficSegment{}, rej
+		}
+	}
+
+	var wdHourly, weHourly []float64
+	if t.colWeekdayHourly >= 0 {
+		if wdHourly, rej = t.profile(record[t.colWeekdayHourly], "weekday_hourly"); rej != nil {
+			return traffic.TrafficSegment{}, rej
+		}
+	}
+	if t.colWeekendHourly >= 0 {
+		if weHourly, rej = t.profile(record[t.colWeekendHourly], "weekend_hourly"); rej != nil {
+			return traffic.TrafficSegment{}, rej
+		}
+	}
+
+	wkt, err := t.geometry(record[t.colGeom])
+	if err != nil {
+		return traffic.TrafficSegment{}, &traffic.Reject{Column: "geometry", Reason: err.Error()}
+	}
+
+	return traffic.TrafficSegment{
+		EdgeID:         edgeID,
+		EdgeIDApprox:   approx,
+		WeekdayTraffic: wd,
+		WeekendTraffic: we,
+		WeekdayHourly:  wdHourly,
+		WeekendHourly:  weHourly,
+		Geometry:       wkt,
+	}, nil
+}
+
+// edgeID разбирает ID ребра. ID бывают больше 2^53, поэтому сначала читаем
+// целое без потерь; float - только для экспоненциальной записи (1.91E+16),
+// и тогда ID приблизительный (approx = true)
+func (t *TrafficReader) edgeID(s string) (id int64, approx bool, rej *traffic.Reject) {
+	s = strings.TrimSpace(s)
+	if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if id <= 0 {
+			return 0, false, &traffic.Reject{Column: "edge_id", Reason: fmt.Sprintf("некорректный ID %q", s)}
+		}
+		return id, false, nil
+	}
+
+	f, err := strconv.ParseFloat(t.number(s), 64)
+	if err != nil || f <= 0 || f != math.Trunc(f) || f >= math.MaxInt64 {
+		return 0, false, &traffic.Reject{Column: "edge_id", Reason: fmt.Sprintf("некорректный ID %q", s)}
+	}
+	return int64(f), f > 1<<53, nil
+}
+
+// count разбирает неотрицательный суточный трафик
+func (t *TrafficReader) count(s, column string) (int, *traffic.Reject) {
+	v, err := strconv.ParseFloat(t.number(s), 64)
+	if err != nil {
+		return 0, &traffic.Reject{Column: column, Reason: fmt.Sprintf("не число: %q", s)}
+	}
+	if v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, &traffic.Reject{Column: column, Reason: fmt.Sprintf("недопустимое значение %q", s)}
+	}
+	return int(math.Round(v)), nil
+}
 
-		// Парсим трафик (берем 2-ю колонку - weekday_traffic)
-		wd, _ := strconv.Atoi(record[1])
+// profile разбирает 24 значения по часам ("120|80|...") и нормирует их в доли.
+// Пустая ячейка - профиля нет (равномерное распределение).
+func (t *TrafficReader) profile(s, column string) ([]float64, *traffic.Reject) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, t.schema.ListSeparator)
+	if len(parts) != traffic.HoursPerDay {
+		return nil, &traffic.Reject{Column: column, Reason: fmt.Sprintf("ожидается %d значений по часам, получено %d", traffic.HoursPerDay, len(parts))}
+	}
 
-		segments = append(segments, traffic.TrafficSegment{
-			EdgeID:         int64(edgeIDFloat),
-			WeekdayTraffic: wd,
-			Geometry:       record[5], // Колонка geometry (WKT)
-		})
+	out := make([]float64, traffic.HoursPerDay)
+	sum := 0.0
+	for h, p := range parts {
+		v, err := strconv.ParseFloat(t.number(p), 64)
+		if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, &traffic.Reject{Column: column, Reason: fmt.Sprintf("час %d: недопустимое значение %q", h, p)}
+		}
+		out[h] = v
+		sum += v
+	}
+	if sum == 0 {
+		return nil, &traffic.Reject{Column: column, Reason: "все значения профиля нулевые"}
 	}
+	for h := range out {
+		out[h] /= sum
+	}
+	return out, nil
+}
+
+// number приводит число к виду для strconv: убирает пробелы и меняет десятичную запятую.
+// Меняется только первая запятая: с двумя запятыми ("1,2,3") число некорректно,
+// и strconv его отвергнет.
+func (t *TrafficReader) number(s string) string {
+	s = strings.TrimSpace(s)
+	if t.schema.DecimalSeparator == "," {
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	return s
+}
+
+// geometry переводит геометрию из формата поставщика в WKT
+func (t *TrafficReader) geometry(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	switch t.schema.GeometryFormat {
+	case config.GeometryWKBHex:
+		g, err := geo.DecodeWKBHex(s)
+		if err != nil {
+			return "", err
+		}
+		return g.WKT(), nil
+	case config.GeometryLatLon:
+		l, err := geo.ParseLatLonPairs(s, t.schema.DecimalSeparator == ",")
+		if err != nil {
+			return "", err
+		}
+		return l.WKT(), nil
+	default:
+		return s, nil
+	}
+}
+
+// RowsRead - сколько строк данных прочитано (без заголовка)
+func (t *TrafficReader) RowsRead() int { return t.read }
+
+// Rejected - сколько строк отброшено как битые
+func (t *TrafficReader) Rejected() int { return t.rejected }
+
+// findColumn ищет колонку по любому из допустимых имен (без учета регистра и BOM)
+func findColumn(header []string, name string, aliases []string) (int, error) {
+	for _, alias := range aliases {
+		for i, h := range header {
+			if normalizeHeader(h) == normalizeHeader(alias) {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("в CSV нет обязательной колонки %s: ожидался заголовок %s, в файле: %s",
+		name, strings.Join(aliases, " или "), strings.Join(header, ", "))
+}
+
+// lookupColumn - индекс необязательной колонки или -1
+func lookupColumn(header []string, aliases []string) int {
+	if i, err := findColumn(header, "", aliases); err == nil {
+		return i
+	}
+	return -1
+}
 
-	return segments, nil
+func normalizeHeader(s string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(s, "\ufeff")))
 }
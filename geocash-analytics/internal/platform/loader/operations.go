@@ -0,0 +1,186 @@
+package loader
+
+import (
+	"encoding/csv"
+	"fmt"
+	"geocash/internal/domain/terminal"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Поддерживаемые форматы времени в выгрузках процессинга
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// LoadTransactionsCSV читает выгрузку транзакций.
+// Колонки: transaction_id, terminal_id, transaction_time, operation_type, amount, currency, status
+func LoadTransactionsCSV(path string) ([]terminal.Transaction, error) {
+	var res []terminal.Transaction
+	err := readOperationsCSV(path, []string{"transaction_id", "terminal_id", "transaction_time", "amount"},
+		func(row csvRow) error {
+			t, err := row.time("transaction_time")
+			if err != nil {
+				return err
+			}
+			amount, err := row.float("amount")
+			if err != nil {
+				return err
+			}
+			res = append(res, terminal.Transaction{
+				TransactionID: row.get("transaction_id"),
+				TerminalID:    row.get("terminal_id"),
+				Time:          t,
+				OperationType: row.getOr("operation_type", "WITHDRAWAL"),
+				Amount:        amount,
+				Currency:      row.getOr("currency", "KZT"),
+				Status:        row.getOr("status", "COMPLETED"),
+			})
+			return nil
+		})
+	return res, err
+}
+
+// LoadLoadingsCSV читает выгрузку замеров остатка.
+// Колонки: terminal_id, record_time, current_balance, max_capacity
+func LoadLoadingsCSV(path string) ([]terminal.CashBalance, error) {
+	var res []terminal.CashBalance
+	err := readOperationsCSV(path, []string{"terminal_id", "record_time", "current_balance", "max_capacity"},
+		func(row csvRow) error {
+			t, err := row.time("record_time")
+			if err != nil {
+				return err
+			}
+			balance, err := row.float("current_balance")
+			if err != nil {
+				return err
+			}
+			capacity, err := row.float("max_capacity")
+			if err != nil {
+				return err
+			}
+			res = append(res, terminal.CashBalance{
+				TerminalID:     row.get("terminal_id"),
+				RecordTime:     t,
+				CurrentBalance: balance,
+				MaxCapacity:    capacity,
+			})
+			return nil
+		})
+	return res, err
+}
+
+// LoadServiceHistoryCSV читает историю обслуживания.
+// Колонки: terminal_id, service_date, service_type, is_critical, description
+func LoadServiceHistoryCSV(path string) ([]terminal.ServiceRecord, error) {
+	var res []terminal.ServiceRecord
+	err := readOperationsCSV(path, []string{"terminal_id", "service_date", "service_type"},
+		func(row csvRow) error {
+			t, err := row.time("service_date")
+			if err != nil {
+				return err
+			}
+			critical := false
+			if v := row.get("is_critical"); v != "" {
+				critical, err = strconv.ParseBool(v)
+				if err != nil {
+					return fmt.Errorf("колонка is_critical: %w", err)
+				}
+			}
+			res = append(res, terminal.ServiceRecord{
+				TerminalID:  row.get("terminal_id"),
+				ServiceDate: t,
+				ServiceType: row.get("service_type"),
+				IsCritical:  critical,
+				Description: row.get("description"),
+			})
+			return nil
+		})
+	return res, err
+}
+
+// csvRow - строка CSV с доступом к колонкам по имени заголовка
+type csvRow struct {
+	cols   map[string]int
+	record []string
+}
+
+func (r csvRow) get(name string) string {
+	i, ok := r.cols[name]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+	return strings.TrimSpace(r.record[i])
+}
+
+func (r csvRow) getOr(name, fallback string) string {
+	if v := r.get(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func (r csvRow) float(name string) (float64, error) {
+	v, err := strconv.ParseFloat(r.get(name), 64)
+	if err != nil {
+		return 0, fmt.Errorf("колонка %s: %w", name, err)
+	}
+	return v, nil
+}
+
+func (r csvRow) time(name string) (time.Time, error) {
+	v := r.get(name)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("колонка %s: неизвестный формат времени %q", name, v)
+}
+
+// readOperationsCSV читает файл с заголовком и вызывает fn для каждой строки.
+// Финансовые данные не пропускаем молча: первая битая строка прерывает чтение.
+func readOperationsCSV(path string, required []string, fn func(row csvRow) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть файл: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать заголовок: %w", err)
+	}
+
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range required {
+		if _, ok := cols[name]; !ok {
+			return fmt.Errorf("%s: нет обязательной колонки %q", path, name)
+		}
+	}
+
+	line := 1
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if err := fn(csvRow{cols: cols, record: record}); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"geocash/internal/ingest"
+)
+
+// RunStore - история импортов в памяти (аналог postgres.ImportRunRepository).
+// После перезапуска история пуста, поэтому файлы импортируются заново.
+type RunStore struct {
+	mu   sync.Mutex
+	runs []ingest.ImportRun
+}
+
+var _ ingest.RunStore = (*RunStore)(nil)
+
+func NewRunStore() *RunStore {
+	return &RunStore{}
+}
+
+func (s *RunStore) FindApplied(ctx context.Context, kind, hash string) (*ingest.ImportRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.runs) - 1; i >= 0; i-- {
+		r := s.runs[i]
+		if r.Kind == kind && r.ContentHash == hash && r.Status == ingest.RunSuccess {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *RunStore) Start(ctx context.Context, run *ingest.ImportRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.ID = int64(len(s.runs) + 1)
+	run.StartedAt = time.Now()
+	s.runs = append(s.runs, *run)
+	return nil
+}
+
+func (s *RunStore) Finish(ctx context.Context, run *ingest.ImportRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	run.FinishedAt = &now
+	s.runs[run.ID-1] = *run
+	return nil
+}
+
+// List - последние запуски, новые первыми
+func (s *RunStore) List(ctx context.Context, limit int) ([]ingest.ImportRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []ingest.ImportRun{}
+	for i := len(s.runs) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, s.runs[i])
+	}
+	return out, nil
+}
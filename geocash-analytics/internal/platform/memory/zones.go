@@ -0,0 +1,300 @@
+// Package memory - хранилища в памяти для запуска без Postgres/PostGIS:
+// демо на ноутбуке и тесты аналитики
+package memory
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"sync"
+
+	"geocash/internal/analytics"
+	"geocash/internal/config"
+	"geocash/internal/domain/city"
+	"geocash/internal/domain/traffic"
+	"geocash/internal/geo"
+	"geocash/internal/ingest"
+)
+
+// zone - аналог строки geo_traffic_zones
+type zone struct {
+	id      int
+	code    string
+	name    string
+	polygon geo.Polygon
+	ring    [][]float64 // внешнее кольцо [lng, lat] для GeoJSON
+
+	score         int
+	raw           float64
+	hasTraffic    bool
+	weekday       float64
+	weekend       float64
+	weekdayHourly []float64
+	weekendHourly []float64
+}
+
+// ZoneStore - зоны трафика в памяти с пространственным индексом.
+// Реализует ingest.TrafficImporter (как postgres.TrafficIntegrator)
+// и traffic.ZoneRepository (как postgres.ZoneRepository).
+type ZoneStore struct {
+	scoreMethod string
+
+	mu        sync.RWMutex
+	zones     []*zone
+	index     *geo.GridIndex
+	terminals map[string]map[string]geo.Point // наши терминалы по городам: id -> точка (для кандидатов на расширение)
+}
+
+var (
+	_ ingest.TrafficImporter = (*ZoneStore)(nil)
+	_ traffic.ZoneRepository = (*ZoneStore)(nil)
+)
+
+// NewZoneStore: indexCell - шаг индекса в градусах (порядка размера зоны)
+func NewZoneStore(scoreMethod string, indexCell float64) *ZoneStore {
+	return &ZoneStore{scoreMethod: scoreMethod, index: geo.NewGridIndex(indexCell)}
+}
+
+// NewGridZoneStore - зоны из ячеек гексагональной сетки всех городов (как `geocash grid generate`)
+func NewGridZoneStore(grid config.GridConfig, cities []city.City, scoreMethod string) *ZoneStore {
+	s := NewZoneStore(scoreMethod, 2*grid.CellRadius)
+	gridSvc := analytics.NewGridService(grid)
+	for _, c := range cities {
+		for _, cell := range gridSvc.Cells(c) {
+			s.AddZone(cell.Code, cell.Code, cell.Polygon)
+		}
+	}
+	return s
+}
+
+// AddZone добавляет зону по внешнему кольцу [lng, lat]
+func (s *ZoneStore) AddZone(code, name string, ring [][]float64) {
+	r := make(geo.Ring, len(ring))
+	for i, p := range ring {
+		r[i] = geo.Point{Lng: p[0], Lat: p[1]}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	z := &zone{id: len(s.zones) + 1, code: code, name: name, polygon: geo.Polygon{r}, ring: ring}
+	s.zones = append(s.zones, z)
+	s.index.Insert(z.id-1, z.polygon.Bounds())
+}
+
+// SetTerminals заменяет наши терминалы города (зоны с терминалом не кандидаты
+// на расширение). Ключ - terminal_id: по нему пропускаются закрытые (closed).
+// Реализует dashboard.TerminalIndex.
+func (s *ZoneStore) SetTerminals(cityID string, terminals map[string]geo.Point) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.terminals == nil {
+		s.terminals = make(map[string]map[string]geo.Point)
+	}
+	s.terminals[cityID] = terminals
+}
+
+// zoneAcc - накопленный трафик зоны за импорт
+type zoneAcc struct {
+	weekday, weekend, raw        float64
+	weekdayHourly, weekendHourly []float64
+}
+
+// ImportTraffic пересекает сегменты с зонами через индекс и обновляет трафик зон.
+// Как и в Postgres, импорт атомарный: при ошибке зоны не меняются, а файл заменяет
+// трафик городов (areas), которых коснулся, и traffic_score считается только по нему.
+// Сегмент засчитывается зоне, если внутри нее лежит ненулевая часть линии.
+func (s *ZoneStore) ImportTraffic(ctx context.Context, src traffic.SegmentSource, areas []geo.Bounds) (traffic.ImportStats, error) {
+	var stats traffic.ImportStats
+	acc := make(map[int]*zoneAcc)
+	touched := make([]bool, len(areas))
+
+	s.mu.RLock()
+	for {
+		if err := ctx.Err(); err != nil {
+			s.mu.RUnlock()
+			return stats, err
+		}
+		seg, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.mu.RUnlock()
+			return stats, fmt.Errorf("ошибка чтения сегментов: %w", err)
+		}
+		stats.SegmentsLoaded++
+
+		g, err := geo.ParseWKT(seg.Geometry)
+		if err != nil {
+			s.mu.RUnlock()
+			return stats, fmt.Errorf("строка %d: %w", seg.Line, err)
+		}
+		for i, a := range areas {
+			touched[i] = touched[i] || a.Intersects(g.Bounds())
+		}
+		if s.addSegment(acc, seg, g) {
+			stats.SegmentsMatched++
+		}
+	}
+	s.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resetZones(areas, touched)
+	imported := make([]*zone, 0, len(acc))
+	for i, a := range acc {
+		z := s.zones[i]
+		z.hasTraffic = true
+		z.weekday, z.weekend, z.raw = a.weekday, a.weekend, a.raw
+		z.weekdayHourly, z.weekendHourly = a.weekdayHourly, a.weekendHourly
+		imported = append(imported, z)
+	}
+	stats.ZonesUpdated = len(acc)
+	s.normalizeScores(imported)
+	log.Printf("✅ Обновлено зон трафика (в памяти): %d", stats.ZonesUpdated)
+	return stats, nil
+}
+
+func (s *ZoneStore) addSegment(acc map[int]*zoneAcc, seg traffic.TrafficSegment, g geo.Geometry) bool {
+	matched := false
+	var weekdayHourly, weekendHourly []float64
+	for _, i := range s.index.Query(g.Bounds()) {
+		length := s.zones[i].polygon.ClipLength(g)
+		if length <= 0 {
+			continue
+		}
+		if weekdayHourly == nil {
+			weekdayHourly = traffic.HourlyTraffic(seg.WeekdayTraffic, seg.WeekdayHourly)
+			weekendHourly = traffic.HourlyTraffic(seg.WeekendTraffic, seg.WeekendHourly)
+		}
+
+		a := acc[i]
+		if a == nil {
+			a = &zoneAcc{
+				weekdayHourly: make([]float64, traffic.HoursPerDay),
+				weekendHourly: make([]float64, traffic.HoursPerDay),
+			}
+			acc[i] = a
+		}
+		a.weekday += float64(seg.WeekdayTraffic)
+		a.weekend += float64(seg.WeekendTraffic)
+		a.raw += float64(seg.WeekdayTraffic) * length
+		for h := range a.weekdayHourly {
+			a.weekdayHourly[h] += weekdayHourly[h]
+			a.weekendHourly[h] += weekendHourly[h]
+		}
+		matched = true
+	}
+	return matched
+}
+
+// resetZones сбрасывает трафик зон в границах городов, которых коснулся файл
+func (s *ZoneStore) resetZones(areas []geo.Bounds, touched []bool) {
+	for _, z := range s.zones {
+		if !z.hasTraffic {
+			continue
+		}
+		for i, a := range areas {
+			if touched[i] && a.Intersects(z.polygon.Bounds()) {
+				*z = zone{id: z.id, code: z.code, name: z.name, polygon: z.polygon, ring: z.ring}
+				break
+			}
+		}
+	}
+}
+
+// normalizeScores - то же, что TrafficIntegrator.normalizeScores в Postgres:
+// шкала строится по зонам этого импорта
+func (s *ZoneStore) normalizeScores(zones []*zone) {
+	maxRaw := 0.0
+	for _, z := range zones {
+		maxRaw = math.Max(maxRaw, z.raw)
+	}
+	sort.SliceStable(zones, func(i, j int) bool { return zones[i].raw < zones[j].raw })
+
+	for i, z := range zones {
+		var score float64
+		switch s.scoreMethod {
+		case config.ScoreLinear:
+			if maxRaw > 0 {
+				score = z.raw / maxRaw
+			}
+		case config.ScoreLog:
+			if maxRaw > 0 {
+				score = math.Log1p(z.raw) / math.Log1p(maxRaw)
+			}
+		default:
+			// percent_rank: (ранг - 1) / (n - 1), у равных значений ранг первого из них
+			rank := i
+			for rank > 0 && zones[rank-1].raw == z.raw {
+				rank--
+			}
+			score = 1
+			if len(zones) > 1 {
+				score = float64(rank) / float64(len(zones)-1)
+			}
+		}
+		z.score = 1 + int(math.Round(99*score))
+	}
+}
+
+// ZonesTraffic - зоны с загруженным трафиком
+func (s *ZoneStore) ZonesTraffic(ctx context.Context, area geo.Bounds, slice traffic.TimeSlice) ([]traffic.ZoneTraffic, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := []traffic.ZoneTraffic{}
+	for _, z := range s.zones {
+		if z.hasTraffic && z.polygon.Bounds().Intersects(area) {
+			out = append(out, z.traffic(slice))
+		}
+	}
+	return out, nil
+}
+
+// ExpansionCandidates - зоны без наших терминалов, самые оживленные в срезе первыми
+func (s *ZoneStore) ExpansionCandidates(ctx context.Context, area geo.Bounds, slice traffic.TimeSlice, closed []string, limit int) ([]traffic.ZoneTraffic, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	skip := make(map[string]bool, len(closed))
+	for _, id := range closed {
+		skip[id] = true
+	}
+	out := []traffic.ZoneTraffic{}
+	for _, z := range s.zones {
+		if z.polygon.Bounds().Intersects(area) && !s.hasTerminal(z, skip) {
+			out = append(out, z.traffic(slice))
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Traffic != out[j].Traffic {
+			return out[i].Traffic > out[j].Traffic
+		}
+		return out[i].TrafficScore > out[j].TrafficScore
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *ZoneStore) hasTerminal(z *zone, skip map[string]bool) bool {
+	for _, terminals := range s.terminals {
+		for id, t := range terminals {
+			if !skip[id] && z.polygon.Contains(t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (z *zone) traffic(slice traffic.TimeSlice) traffic.ZoneTraffic {
+	t := traffic.ZoneTraffic{ID: z.id, Code: z.code, Name: z.name, Polygon: z.ring, TrafficScore: z.score}
+	if z.hasTraffic {
+		t.Traffic = slice.Traffic(z.weekdayHourly, z.weekday, z.weekendHourly, z.weekend)
+	}
+	return t
+}
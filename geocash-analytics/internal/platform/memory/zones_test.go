@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"context"
+	"io"
+	"math"
+	"testing"
+
+	"geocash/internal/config"
+	"geocash/internal/domain/traffic"
+	"geocash/internal/geo"
+)
+
+// segments - traffic.SegmentSource из среза
+type segments []traffic.TrafficSegment
+
+func (s *segments) Next() (traffic.TrafficSegment, error) {
+	if len(*s) == 0 {
+		return traffic.TrafficSegment{}, io.EOF
+	}
+	seg := (*s)[0]
+	*s = (*s)[1:]
+	return seg, nil
+}
+
+// square - кольцо квадрата 0.01° с углом (lng, lat)
+func square(lng, lat float64) [][]float64 {
+	return [][]float64{{lng, lat}, {lng + 0.01, lat}, {lng + 0.01, lat + 0.01}, {lng, lat + 0.01}, {lng, lat}}
+}
+
+// cityAreas - границы двух городов: квадраты тестов в первом
+var cityAreas = []geo.Bounds{
+	{MinLng: 71.3, MinLat: 51.0, MaxLng: 71.6, MaxLat: 51.2},
+	{MinLng: 76.7, MinLat: 43.1, MaxLng: 77.1, MaxLat: 43.4},
+}
+
+func TestZoneStoreImportTraffic(t *testing.T) {
+	s := NewZoneStore(config.ScoreLinear, 0.02)
+	s.AddZone("west", "Запад", square(71.40, 51.10))
+	s.AddZone("east", "Восток", square(71.41, 51.10))
+	s.AddZone("empty", "Без трафика", square(71.45, 51.10))
+
+	src := &segments{
+		// Целиком в западной зоне
+		{EdgeID: 1, WeekdayTraffic: 1000, WeekendTraffic: 500, Geometry: "LINESTRING(71.402 51.105,71.408 51.105)", Line: 2},
+		// Пересекает обе зоны: половина длины в каждой
+		{EdgeID: 2, WeekdayTraffic: 200, WeekendTraffic: 200, Geometry: "LINESTRING(71.405 51.106,71.415 51.106)", Line: 3},
+		// Вне зон
+		{EdgeID: 3, WeekdayTraffic: 999, WeekendTraffic: 999, Geometry: "LINESTRING(71.50 51.50,71.51 51.51)", Line: 4},
+	}
+	stats, err := s.ImportTraffic(context.Background(), src, cityAreas)
+	if err != nil {
+		t.Fatalf("ImportTraffic: %v", err)
+	}
+	if stats.SegmentsLoaded != 3 || stats.SegmentsMatched != 2 || stats.ZonesUpdated != 2 {
+		t.Errorf("stats = %+v, want loaded 3, matched 2, zones 2", stats)
+	}
+
+	area := geo.Bounds{MinLng: 71.3, MinLat: 51.0, MaxLng: 71.6, MaxLat: 51.2}
+	zones, err := s.ZonesTraffic(context.Background(), area, traffic.AllDay)
+	if err != nil {
+		t.Fatalf("ZonesTraffic: %v", err)
+	}
+	byCode := make(map[string]traffic.ZoneTraffic)
+	for _, z := range zones {
+		byCode[z.Code] = z
+	}
+	if len(zones) != 2 {
+		t.Fatalf("ZonesTraffic = %d зон, want 2 (зона без трафика не отдается)", len(zones))
+	}
+
+	west, east := byCode["west"], byCode["east"]
+	// raw: запад 1000*L + 200*L/2, восток 200*L/2 (L - длина 0.006° и 0.01°)
+	if west.TrafficScore != 100 {
+		t.Errorf("west score = %d, want 100", west.TrafficScore)
+	}
+	if east.TrafficScore <= 1 || east.TrafficScore >= west.TrafficScore {
+		t.Errorf("east score = %d, want between 1 and %d", east.TrafficScore, west.TrafficScore)
+	}
+	wantWest := (5*1200.0 + 2*700.0) / 7
+	if math.Abs(west.Traffic-wantWest) > 1e-9 {
+		t.Errorf("west traffic = %g, want %g", west.Traffic, wantWest)
+	}
+	if math.Abs(east.Traffic-200) > 1e-9 {
+		t.Errorf("east traffic = %g, want 200", east.Traffic)
+	}
+
+	// Срез по часам: без профиля трафик равномерный
+	morning := traffic.TimeSlice{Day: traffic.DayWeekday, FromHour: 7, ToHour: 10}
+	zones, _ = s.ZonesTraffic(context.Background(), area, morning)
+	for _, z := range zones {
+		if z.Code == "west" && math.Abs(z.Traffic-1200.0*3/24) > 1e-9 {
+			t.Errorf("west weekday 7-10 = %g, want %g", z.Traffic, 1200.0*3/24)
+		}
+	}
+}
+
+// Повторный импорт заменяет трафик города: зоны, которых нет в новом файле,
+// теряют прошлый трафик, а шкала строится только по новому файлу.
+// Город, которого файл не коснулся, не меняется.
+func TestZoneStoreReimportReplacesCityTraffic(t *testing.T) {
+	s := NewZoneStore(config.ScoreLinear, 0.02)
+	s.AddZone("west", "Запад", square(71.40, 51.10))
+	s.AddZone("east", "Восток", square(71.41, 51.10))
+	s.AddZone("south", "Юг", square(76.90, 43.20))
+
+	first := &segments{
+		{EdgeID: 1, WeekdayTraffic: 10000, WeekendTraffic: 10000, Geometry: "LINESTRING(71.402 51.105,71.408 51.105)", Line: 2},
+		{EdgeID: 2, WeekdayTraffic: 100, WeekendTraffic: 100, Geometry: "LINESTRING(71.412 51.105,71.418 51.105)", Line: 3},
+		{EdgeID: 3, WeekdayTraffic: 500, WeekendTraffic: 500, Geometry: "LINESTRING(76.902 43.205,76.908 43.205)", Line: 4},
+	}
+	if _, err := s.ImportTraffic(context.Background(), first, cityAreas); err != nil {
+		t.Fatalf("first import: %v", err)
+	}
+	// Второй файл - только восток первого города, и трафика меньше
+	second := &segments{
+		{EdgeID: 2, WeekdayTraffic: 50, WeekendTraffic: 50, Geometry: "LINESTRING(71.412 51.105,71.418 51.105)", Line: 2},
+	}
+	stats, err := s.ImportTraffic(context.Background(), second, cityAreas)
+	if err != nil {
+		t.Fatalf("second import: %v", err)
+	}
+	if stats.ZonesUpdated != 1 {
+		t.Errorf("ZonesUpdated = %d, want 1", stats.ZonesUpdated)
+	}
+
+	all := geo.Bounds{MinLng: 70, MinLat: 40, MaxLng: 80, MaxLat: 55}
+	zones, _ := s.ZonesTraffic(context.Background(), all, traffic.AllDay)
+	byCode := make(map[string]traffic.ZoneTraffic)
+	for _, z := range zones {
+		byCode[z.Code] = z
+	}
+	if _, ok := byCode["west"]; ok {
+		t.Errorf("west still has traffic from the first import: %+v", byCode["west"])
+	}
+	// Восток - единственная зона импорта, значит самая оживленная
+	if east := byCode["east"]; east.TrafficScore != 100 || math.Abs(east.Traffic-50) > 1e-9 {
+		t.Errorf("east = score %d, traffic %g; want 100, 50", east.TrafficScore, east.Traffic)
+	}
+	if south, ok := byCode["south"]; !ok || math.Abs(south.Traffic-500) > 1e-9 {
+		t.Errorf("south = %+v, want traffic 500 from the first import", south)
+	}
+}
+
+func TestZoneStoreImportTrafficBadGeometry(t *testing.T) {
+	s := NewZoneStore(config.ScorePercentile, 0.02)
+	s.AddZone("west", "Запад", square(71.40, 51.10))
+
+	src := &segments{
+		{EdgeID: 1, WeekdayTraffic: 1000, Geometry: "LINESTRING(71.402 51.105,71.408 51.105)", Line: 2},
+		{EdgeID: 2, WeekdayTraffic: 1000, Geometry: "LINESTRING(oops)", Line: 3},
+	}
+	if _, err := s.ImportTraffic(context.Background(), src, cityAreas); err == nil {
+		t.Fatal("ImportTraffic: want error for bad WKT")
+	}
+	// Импорт атомарный: зоны не изменились
+	zones, _ := s.ZonesTraffic(context.Background(), geo.Bounds{MinLng: 71, MinLat: 51, MaxLng: 72, MaxLat: 52}, traffic.AllDay)
+	if len(zones) != 0 {
+		t.Errorf("ZonesTraffic after failed import = %d зон, want 0", len(zones))
+	}
+}
+
+func TestZoneStoreExpansionCandidates(t *testing.T) {
+	s := NewZoneStore(config.ScorePercentile, 0.02)
+	s.AddZone("west", "Запад", square(71.40, 51.10))
+	s.AddZone("east", "Восток", square(71.41, 51.10))
+	s.SetTerminals("astana", map[string]geo.Point{"T1": {Lng: 71.405, Lat: 51.105}})
+
+	src := &segments{
+		{EdgeID: 1, WeekdayTraffic: 1000, WeekendTraffic: 1000, Geometry: "LINESTRING(71.402 51.105,71.408 51.105)", Line: 2},
+		{EdgeID: 2, WeekdayTraffic: 100, WeekendTraffic: 100, Geometry: "LINESTRING(71.412 51.105,71.418 51.105)", Line: 3},
+	}
+	if _, err := s.ImportTraffic(context.Background(), src, cityAreas); err != nil {
+		t.Fatalf("ImportTraffic: %v", err)
+	}
+
+	area := geo.Bounds{MinLng: 71, MinLat: 51, MaxLng: 72, MaxLat: 52}
+	got, _ := s.ExpansionCandidates(context.Background(), area, traffic.AllDay, nil, 10)
+	if len(got) != 1 || got[0].Code != "east" {
+		t.Errorf("candidates = %+v, want only east (в западной зоне есть терминал)", got)
+	}
+	// Терминал закрыт - западная зона снова кандидат и идет первой
+	got, _ = s.ExpansionCandidates(context.Background(), area, traffic.AllDay, []string{"T1"}, 10)
+	if len(got) != 2 || got[0].Code != "west" {
+		t.Errorf("candidates with T1 closed = %+v, want west, east", got)
+	}
+	// Новый набор терминалов города заменяет прежний
+	s.SetTerminals("astana", map[string]geo.Point{"T2": {Lng: 71.415, Lat: 51.105}})
+	got, _ = s.ExpansionCandidates(context.Background(), area, traffic.AllDay, nil, 10)
+	if len(got) != 1 || got[0].Code != "west" {
+		t.Errorf("candidates after SetTerminals = %+v, want only west", got)
+	}
+}
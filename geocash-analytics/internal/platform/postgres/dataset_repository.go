@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"geocash/internal/domain/terminal"
+)
+
+// DatasetRepository реализует terminal.DatasetStore поверх таблицы atm_datasets
+type DatasetRepository struct {
+	db *sql.DB
+}
+
+func NewDatasetRepository(db *sql.DB) *DatasetRepository {
+	return &DatasetRepository{db: db}
+}
+
+func (r *DatasetRepository) SaveDataset(ctx context.Context, d terminal.Dataset) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("ошибка записи набора %s: %w", d.CityID, err)
+	}
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO atm_datasets (city_id, updated_at, data) VALUES ($1, $2, $3)
+		ON CONFLICT (city_id) DO UPDATE SET updated_at = EXCLUDED.updated_at, data = EXCLUDED.data`,
+		d.CityID, d.UpdatedAt.UTC(), data,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка записи набора %s: %w", d.CityID, err)
+	}
+	return nil
+}
+
+func (r *DatasetRepository) LoadDataset(ctx context.Context, cityID string) (terminal.Dataset, error) {
+	var d terminal.Dataset
+	var data []byte
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM atm_datasets WHERE city_id = $1`, cityID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return d, terminal.ErrNotFound
+	}
+	if err != nil {
+		return d, fmt.Errorf("ошибка чтения набора %s: %w", cityID, err)
+	}
+	if d, err = terminal.DecodeDataset(data); err != nil {
+		return d, fmt.Errorf("набор %s: %w", cityID, err)
+	}
+	return d, nil
+}
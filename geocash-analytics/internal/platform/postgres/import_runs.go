@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"geocash/internal/ingest"
+)
+
+// ImportRunRepository реализует ingest.RunStore поверх таблицы import_runs
+type ImportRunRepository struct {
+	db *sql.DB
+}
+
+func NewImportRunRepository(db *sql.DB) *ImportRunRepository {
+	return &ImportRunRepository{db: db}
+}
+
+const importRunColumns = `
+	id, kind, file_path, content_hash, status, forced,
+	rows_read, rows_rejected, rows_loaded, rows_matched, zones_updated,
+	COALESCE(rejects_file, ''), COALESCE(error, ''), started_at, finished_at`
+
+// FindApplied ищет последний успешный импорт файла с таким же содержимым
+func (r *ImportRunRepository) FindApplied(ctx context.Context, kind, hash string) (*ingest.ImportRun, error) {
+	query := `SELECT ` + importRunColumns + `
+		FROM import_runs
+		WHERE kind = $1 AND content_hash = $2 AND status = $3
+		ORDER BY started_at DESC
+		LIMIT 1`
+
+	run, err := scanImportRun(r.db.QueryRowContext(ctx, query, kind, hash, ingest.RunSuccess))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска импорта: %w", err)
+	}
+	return &run, nil
+}
+
+// Start создает запись о начале импорта
+func (r *ImportRunRepository) Start(ctx context.Context, run *ingest.ImportRun) error {
+	query := `
+		INSERT INTO import_runs (kind, file_path, content_hash, status, forced)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, started_at
+	`
+	err := r.db.QueryRowContext(ctx, query, run.Kind, run.FilePath, run.ContentHash, run.Status, run.Forced).
+		Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка записи импорта: %w", err)
+	}
+	return nil
+}
+
+// Finish сохраняет итог импорта
+func (r *ImportRunRepository) Finish(ctx context.Context, run *ingest.ImportRun) error {
+	query := `
+		UPDATE import_runs SET
+			status = $2,
+			rows_read = $3,
+			rows_rejected = $4,
+			rows_loaded = $5,
+			rows_matched = $6,
+			zones_updated = $7,
+			rejects_file = NULLIF($8, ''),
+			error = NULLIF($9, ''),
+			finished_at = NOW()
+		WHERE id = $1
+		RETURNING finished_at
+	`
+	var finished time.Time
+	s := run.Stats
+	err := r.db.QueryRowContext(ctx, query, run.ID, run.Status,
+		s.SegmentsRead, s.SegmentsRejected, s.SegmentsLoaded, s.SegmentsMatched, s.ZonesUpdated,
+		s.RejectsFile, run.Error,
+	).Scan(&finished)
+	if err != nil {
+		return fmt.Errorf("ошибка записи итога импорта: %w", err)
+	}
+	run.FinishedAt = &finished
+	return nil
+}
+
+// List возвращает последние запуски импорта
+func (r *ImportRunRepository) List(ctx context.Context, limit int) ([]ingest.ImportRun, error) {
+	query := `SELECT ` + importRunColumns + `
+		FROM import_runs
+		ORDER BY started_at DESC
+		LIMIT $1`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения истории импортов: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []ingest.ImportRun{}
+	for rows.Next() {
+		run, err := scanImportRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения истории импортов: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// rowScanner - общий интерфейс *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanImportRun(row rowScanner) (ingest.ImportRun, error) {
+	var run ingest.ImportRun
+	var finished sql.NullTime
+	err := row.Scan(
+		&run.ID, &run.Kind, &run.FilePath, &run.ContentHash, &run.Status, &run.Forced,
+		&run.Stats.SegmentsRead, &run.Stats.SegmentsRejected, &run.Stats.SegmentsLoaded,
+		&run.Stats.SegmentsMatched, &run.Stats.ZonesUpdated,
+		&run.Stats.RejectsFile, &run.Error, &run.StartedAt, &finished,
+	)
+	if finished.Valid {
+		run.FinishedAt = &finished.Time
+	}
+	return run, err
+}
@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"geocash/internal/domain/terminal"
+)
+
+// Размер пачки для batch insert (7 колонок * 1000 строк < лимита 65535 параметров)
+const operationsBatchSize = 1000
+
+// OperationsImporter загружает выгрузки транзакций, замеров остатка и
+// истории обслуживания. Повторная загрузка того же файла не создает дублей.
+type OperationsImporter struct {
+	db *sql.DB
+}
+
+func NewOperationsImporter(db *sql.DB) *OperationsImporter {
+	return &OperationsImporter{db: db}
+}
+
+// SaveTransactions сохраняет транзакции, возвращает количество новых строк
+func (o *OperationsImporter) SaveTransactions(ctx context.Context, items []terminal.Transaction) (int64, error) {
+	return o.insertBatches(ctx, len(items),
+		`INSERT INTO terminal_transactions
+			(transaction_id, terminal_id, transaction_time, operation_type, amount, currency, status)
+		VALUES %s
+		ON CONFLICT (transaction_id) DO NOTHING`,
+		func(i int) []interface{} {
+			t := items[i]
+			return []interface{}{t.TransactionID, t.TerminalID, t.Time, t.OperationType, t.Amount, t.Currency, t.Status}
+		})
+}
+
+// SaveLoadings сохраняет замеры остатка, возвращает количество новых строк
+func (o *OperationsImporter) SaveLoadings(ctx context.Context, items []terminal.CashBalance) (int64, error) {
+	return o.insertBatches(ctx, len(items),
+		`INSERT INTO terminal_loadings (terminal_id, record_time, current_balance, max_capacity)
+		VALUES %s
+		ON CONFLICT (terminal_id, record_time) DO NOTHING`,
+		func(i int) []interface{} {
+			l := items[i]
+			return []interface{}{l.TerminalID, l.RecordTime, l.CurrentBalance, l.MaxCapacity}
+		})
+}
+
+// SaveServiceHistory сохраняет историю обслуживания, возвращает количество новых строк
+func (o *OperationsImporter) SaveServiceHistory(ctx context.Context, items []terminal.ServiceRecord) (int64, error) {
+	return o.insertBatches(ctx, len(items),
+		`INSERT INTO terminal_service_history (terminal_id, service_date, service_type, is_critical, description)
+		VALUES %s
+		ON CONFLICT (terminal_id, service_date, service_type) DO NOTHING`,
+		func(i int) []interface{} {
+			s := items[i]
+			return []interface{}{s.TerminalID, s.ServiceDate, s.ServiceType, s.IsCritical, s.Description}
+		})
+}
+
+// insertBatches вставляет n строк пачками в одной транзакции.
+// query содержит %s на месте списка VALUES, row возвращает значения i-й строки.
+func (o *OperationsImporter) insertBatches(ctx context.Context, n int, query string, row func(i int) []interface{}) (int64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var inserted int64
+	for start := 0; start < n; start += operationsBatchSize {
+		end := start + operationsBatchSize
+		if end > n {
+			end = n
+		}
+
+		valueStrings := make([]string, 0, end-start)
+		var valueArgs []interface{}
+		for i := start; i < end; i++ {
+			args := row(i)
+			placeholders := make([]string, len(args))
+			for j := range args {
+				placeholders[j] = fmt.Sprintf("$%d", len(valueArgs)+j+1)
+			}
+			valueStrings = append(valueStrings, "("+strings.Join(placeholders, ", ")+")")
+			valueArgs = append(valueArgs, args...)
+		}
+
+		res, err := tx.ExecContext(ctx, fmt.Sprintf(query, strings.Join(valueStrings, ",")), valueArgs...)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка вставки batch: %w", err)
+		}
+		count, _ := res.RowsAffected()
+		inserted += count
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"geocash/internal/config"
+	"geocash/internal/domain/city"
+	"geocash/internal/domain/terminal"
+)
+
+// RegistrySource - наши банкоматы из справочника terminals (реализует terminal.Source).
+// Точнее OSM: там есть все активные терминалы, а не только нанесенные на карту.
+type RegistrySource struct {
+	db   *sql.DB
+	bank config.BankConfig
+}
+
+func NewRegistrySource(db *sql.DB, bank config.BankConfig) *RegistrySource {
+	return &RegistrySource{db: db, bank: bank}
+}
+
+func (s *RegistrySource) Name() string { return config.ATMSourceRegistry }
+
+// FetchATMs - активные терминалы с координатами в границах города
+func (s *RegistrySource) FetchATMs(ctx context.Context, c city.City) ([]terminal.ATM, error) {
+	query := `
+		SELECT terminal_id, COALESCE(NULLIF(address, ''), terminal_id), ST_Y(location), ST_X(location)
+		FROM terminals
+		WHERE is_active
+		  AND location IS NOT NULL
+		  AND location && ST_MakeEnvelope($1, $2, $3, $4, 4326)
+		ORDER BY terminal_id
+	`
+	rows, err := s.db.QueryContext(ctx, query, c.BBox.MinLng, c.BBox.MinLat, c.BBox.MaxLng, c.BBox.MaxLat)
+	if err != nil {
+		return nil, terminal.NewSourceError(s.Name(), terminal.ErrSourceUnavailable, err)
+	}
+	defer rows.Close()
+
+	var atms []terminal.ATM
+	for rows.Next() {
+		atm := terminal.ATM{IsForte: true, Bank: s.bank.Name, BankID: s.bank.ID}
+		if err := rows.Scan(&atm.TerminalID, &atm.Name, &atm.Lat, &atm.Lng); err != nil {
+			return nil, terminal.NewSourceError(s.Name(), terminal.ErrSourceBadData, err)
+		}
+		atm.ID = s.Name() + ":" + atm.TerminalID
+		atms = append(atms, atm)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, terminal.NewSourceError(s.Name(), terminal.ErrSourceUnavailable, err)
+	}
+	return atms, nil
+}
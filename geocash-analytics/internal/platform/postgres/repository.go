@@ -25,7 +25,7 @@ func NewAnalyticsRepository(db *sql.DB) *AnalyticsRepository {
 // GetPerformanceMetricsByPeriod выполняет сложный SQL-запрос для сбора метрик
 func (r *AnalyticsRepository) GetPerformanceMetricsByPeriod(
 	ctx context.Context,
-	terminalID int,
+	terminalID string,
 	start time.Time,
 	end time.Time,
 ) (analytics.PerformanceMetrics, error) {
@@ -46,7 +46,7 @@ func (r *AnalyticsRepository) GetPerformanceMetricsByPeriod(
 	-- 2. Считаем среднюю загрузку (в процентах: balance / capacity)
 	load_stats AS (
 		SELECT 
-			COALESCE(AVG(current_balance / NULLIF(max_capacity, 0)), 0) as avg_load_percent
+			COALESCE(AVG(current_balance / NULLIF(max_capacity, 0)) * 100, 0) as avg_load_percent
 		FROM terminal_loadings
 		WHERE terminal_id = $1 
 		  AND record_time BETWEEN $2 AND $3
@@ -88,7 +88,7 @@ func (r *AnalyticsRepository) GetPerformanceMetricsByPeriod(
 }
 
 // GetLastKnownBalance получает последнюю запись о загрузке
-func (r *AnalyticsRepository) GetLastKnownBalance(ctx context.Context, terminalID int) (terminal.CashBalance, error) {
+func (r *AnalyticsRepository) GetLastKnownBalance(ctx context.Context, terminalID string) (terminal.CashBalance, error) {
 	query := `
 		SELECT record_time, current_balance, max_capacity
 		FROM terminal_loadings
@@ -108,10 +108,40 @@ func (r *AnalyticsRepository) GetLastKnownBalance(ctx context.Context, terminalI
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return terminal.CashBalance{}, fmt.Errorf("данные о балансе не найдены для терминала %d", terminalID)
+			return terminal.CashBalance{}, fmt.Errorf("данные о балансе не найдены для терминала %s: %w", terminalID, terminal.ErrNotFound)
 		}
 		return terminal.CashBalance{}, fmt.Errorf("ошибка получения баланса: %w", err)
 	}
 
 	return balance, nil
 }
+
+// TerminalExists проверяет наличие терминала в справочнике
+func (r *AnalyticsRepository) TerminalExists(ctx context.Context, terminalID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM terminals WHERE terminal_id = $1)`, terminalID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("ошибка поиска терминала: %w", err)
+	}
+	return exists, nil
+}
+
+// SaveEfficiencyReport сохраняет рассчитанный отчет в efficiency_reports
+func (r *AnalyticsRepository) SaveEfficiencyReport(ctx context.Context, report *analytics.EfficiencyReport) error {
+	query := `
+		INSERT INTO efficiency_reports (terminal_id, period_start, period_end, efficiency_status, recommendation)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, calculated_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		report.TerminalID,
+		report.PeriodStart,
+		report.PeriodEnd,
+		report.Status,
+		report.Recommendation,
+	).Scan(&report.ID, &report.CalculatedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения отчета: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"geocash/internal/domain/terminal"
+
+	"github.com/lib/pq"
+)
+
+// SnapshotRepository реализует terminal.SnapshotStore поверх таблиц
+// osm_snapshots, osm_snapshot_atms и osm_network_changes
+type SnapshotRepository struct {
+	db *sql.DB
+	// moveThresholdM - смещение меньше этого считается уточнением координат, а не переносом
+	moveThresholdM float64
+	// retention - срок хранения снимков; 0 - не удалять
+	retention time.Duration
+}
+
+func NewSnapshotRepository(db *sql.DB, moveThresholdM float64, retention time.Duration) *SnapshotRepository {
+	return &SnapshotRepository{db: db, moveThresholdM: moveThresholdM, retention: retention}
+}
+
+// SaveSnapshot сохраняет снимок и изменения относительно предыдущего в одной транзакции.
+// Ответ без банкоматов с OSM id не сохраняется: пустой ответ Overpass (обрезан по
+// таймауту) иначе выглядел бы как закрытие всей сети. Снимок без изменений тоже не
+// сохраняется (повторы после сбоев иначе копят одинаковые снимки): следующий
+// сравнивается с последним сохраненным. Снимки старше срока хранения удаляются.
+func (r *SnapshotRepository) SaveSnapshot(ctx context.Context, cityID, source string, atms []terminal.ATM) (terminal.SnapshotStats, error) {
+	var stats terminal.SnapshotStats
+	if !hasOSMID(atms) {
+		return stats, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return stats, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var takenAt time.Time
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO osm_snapshots (city_id, source) VALUES ($1, $2) RETURNING id, taken_at`,
+		cityID, source,
+	).Scan(&stats.SnapshotID, &takenAt)
+	if err != nil {
+		return stats, fmt.Errorf("ошибка записи снимка: %w", err)
+	}
+
+	if stats.ATMs, err = r.copyATMs(ctx, tx, stats.SnapshotID, atms); err != nil {
+		return stats, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE osm_snapshots SET atm_count = $2 WHERE id = $1`, stats.SnapshotID, stats.ATMs); err != nil {
+		return stats, fmt.Errorf("ошибка записи снимка: %w", err)
+	}
+
+	// Предыдущий снимок того же города и источника
+	var prevID int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT id FROM osm_snapshots
+		WHERE city_id = $1 AND source = $2 AND id <> $3
+		ORDER BY taken_at DESC, id DESC
+		LIMIT 1`,
+		cityID, source, stats.SnapshotID,
+	).Scan(&prevID)
+	switch {
+	case err == sql.ErrNoRows:
+		stats.First = true
+	case err != nil:
+		return stats, fmt.Errorf("ошибка поиска предыдущего снимка: %w", err)
+	default:
+		if err := r.diff(ctx, tx, &stats, prevID, cityID, takenAt); err != nil {
+			return stats, err
+		}
+		if stats.Added+stats.Removed+stats.Moved == 0 {
+			// Откат транзакции: в базе остается предыдущий снимок
+			stats.SnapshotID, stats.Unchanged = prevID, true
+			return stats, nil
+		}
+	}
+
+	if err := r.prune(ctx, tx, cityID, source, stats.SnapshotID); err != nil {
+		return stats, err
+	}
+	if err := tx.Commit(); err != nil {
+		return stats, fmt.Errorf("ошибка фиксации снимка: %w", err)
+	}
+	return stats, nil
+}
+
+// prune удаляет снимки города и источника старше срока хранения, кроме последнего
+// (keepID); изменения сети остаются, ссылки на снимки в них обнуляются
+func (r *SnapshotRepository) prune(ctx context.Context, tx *sql.Tx, cityID, source string, keepID int64) error {
+	if r.retention <= 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM osm_snapshots
+		WHERE city_id = $1 AND source = $2 AND id <> $3 AND taken_at < NOW() - $4::double precision * INTERVAL '1 second'`,
+		cityID, source, keepID, r.retention.Seconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления старых снимков: %w", err)
+	}
+	return nil
+}
+
+// copyATMs копирует банкоматы с OSM id в снимок
+func (r *SnapshotRepository) copyATMs(ctx context.Context, tx *sql.Tx, snapshotID int64, atms []terminal.ATM) (int, error) {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("osm_snapshot_atms",
+		"snapshot_id", "osm_id", "bank_id", "bank", "name", "location"))
+	if err != nil {
+		return 0, fmt.Errorf("ошибка запуска COPY: %w", err)
+	}
+	defer stmt.Close()
+
+	seen := make(map[string]bool, len(atms))
+	for _, atm := range atms {
+		if atm.OSMID == "" || seen[atm.OSMID] {
+			continue
+		}
+		seen[atm.OSMID] = true
+		location := fmt.Sprintf("SRID=4326;POINT(%.7f %.7f)", atm.Lng, atm.Lat)
+		if _, err := stmt.ExecContext(ctx, snapshotID, atm.OSMID, nullString(atm.BankID), atm.Bank, atm.Name, location); err != nil {
+			return 0, fmt.Errorf("ошибка COPY: %w", err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, fmt.Errorf("ошибка завершения COPY: %w", err)
+	}
+	return len(seen), nil
+}
+
+// diff записывает added/removed/moved между снимками prevID и stats.SnapshotID
+func (r *SnapshotRepository) diff(ctx context.Context, tx *sql.Tx, stats *terminal.SnapshotStats, prevID int64, cityID string, takenAt time.Time) error {
+	query := `
+		WITH cur AS (
+			SELECT * FROM osm_snapshot_atms WHERE snapshot_id = $1
+		), prev AS (
+			SELECT * FROM osm_snapshot_atms WHERE snapshot_id = $2
+		), changes AS (
+			SELECT 'added' AS change, c.osm_id, c.bank_id, c.bank, c.name, c.location,
+				NULL::geometry AS prev_location, NULL::double precision AS moved_m
+			FROM cur c
+			WHERE NOT EXISTS (SELECT 1 FROM prev p WHERE p.osm_id = c.osm_id)
+			UNION ALL
+			SELECT 'removed', p.osm_id, p.bank_id, p.bank, p.name, p.location, NULL, NULL
+			FROM prev p
+			WHERE NOT EXISTS (SELECT 1 FROM cur c WHERE c.osm_id = p.osm_id)
+			UNION ALL
+			SELECT 'moved', c.osm_id, c.bank_id, c.bank, c.name, c.location, p.location,
+				ST_Distance(c.location::geography, p.location::geography)
+			FROM cur c
+			JOIN prev p ON p.osm_id = c.osm_id
+			WHERE ST_Distance(c.location::geography, p.location::geography) > $5
+		), ins AS (
+			INSERT INTO osm_network_changes (
+				snapshot_id, prev_snapshot_id, city_id, change, osm_id,
+				bank_id, bank, name, location, prev_location, moved_m, detected_at
+			)
+			SELECT $1, $2, $3, change, osm_id, bank_id, bank, name, location, prev_location, moved_m, $4
+			FROM changes
+			RETURNING change
+		)
+		SELECT
+			COUNT(*) FILTER (WHERE change = 'added'),
+			COUNT(*) FILTER (WHERE change = 'removed'),
+			COUNT(*) FILTER (WHERE change = 'moved')
+		FROM ins
+	`
+	err := tx.QueryRowContext(ctx, query, stats.SnapshotID, prevID, cityID, takenAt, r.moveThresholdM).
+		Scan(&stats.Added, &stats.Removed, &stats.Moved)
+	if err != nil {
+		return fmt.Errorf("ошибка сравнения снимков: %w", err)
+	}
+	return nil
+}
+
+// NetworkChanges - изменения сети города за период, по порядку обнаружения
+func (r *SnapshotRepository) NetworkChanges(ctx context.Context, cityID string, from, to time.Time) ([]terminal.NetworkChange, error) {
+	query := `
+		SELECT
+			change, osm_id, COALESCE(bank_id, ''), COALESCE(bank, ''), COALESCE(name, ''),
+			ST_Y(location), ST_X(location), ST_Y(prev_location), ST_X(prev_location),
+			COALESCE(moved_m, 0), detected_at
+		FROM osm_network_changes
+		WHERE city_id = $1 AND detected_at >= $2 AND detected_at < $3
+		ORDER BY detected_at, bank, osm_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, cityID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения изменений сети: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []terminal.NetworkChange{}
+	for rows.Next() {
+		var c terminal.NetworkChange
+		var prevLat, prevLng sql.NullFloat64
+		err := rows.Scan(&c.Change, &c.OSMID, &c.BankID, &c.Bank, &c.Name,
+			&c.Lat, &c.Lng, &prevLat, &prevLng, &c.MovedM, &c.DetectedAt)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения изменений сети: %w", err)
+		}
+		c.PrevLat, c.PrevLng = prevLat.Float64, prevLng.Float64
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+func hasOSMID(atms []terminal.ATM) bool {
+	for _, atm := range atms {
+		if atm.OSMID != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// nullString - пустая строка как NULL
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
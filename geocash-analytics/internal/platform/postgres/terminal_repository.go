@@ -0,0 +1,212 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"geocash/internal/config"
+	"geocash/internal/domain/city"
+	"geocash/internal/domain/terminal"
+)
+
+// Окно, за которое считаются средние показатели
+const statsWindowDays = 30
+
+// TerminalRepository реализует terminal.Repository поверх таблиц
+// terminals, cash_levels, client_complaints, maintenance_logs и daily_stats
+type TerminalRepository struct {
+	db   *sql.DB
+	bank config.BankConfig
+}
+
+// NewTerminalRepository создает новый экземпляр репозитория
+func NewTerminalRepository(db *sql.DB, bank config.BankConfig) *TerminalRepository {
+	return &TerminalRepository{
+		db:   db,
+		bank: bank,
+	}
+}
+
+// EnrichATM наполняет банкомат реальной статистикой: кассеты, простои, частота
+// снятий, жалобы. Банкомат из справочника уже знает свой terminal_id; остальные
+// (из OSM, обхода) сопоставляются с ближайшим терминалом в радиусе MatchRadiusM.
+func (r *TerminalRepository) EnrichATM(ctx context.Context, atm *terminal.ATM) error {
+	atm.IsForte = true
+	atm.Bank = r.bank.Name
+
+	// 1. Без terminal_id сопоставляем точку с терминалом по координатам.
+	// Района в справочнике нет (city - это город), поэтому District не заполняем
+	if atm.TerminalID == "" {
+		if err := r.matchTerminal(ctx, atm); err != nil {
+			return err
+		}
+	}
+
+	since := time.Now().AddDate(0, 0, -statsWindowDays)
+
+	if err := r.loadDailyStats(ctx, atm, since); err != nil {
+		return err
+	}
+	if err := r.loadCassettes(ctx, atm, since); err != nil {
+		return err
+	}
+	if err := r.loadDowntime(ctx, atm, since); err != nil {
+		return err
+	}
+	if err := r.loadComplaints(ctx, atm); err != nil {
+		return err
+	}
+
+	atm.EfficiencyStatus = terminal.EvaluateEfficiency(atm)
+	atm.Provenance.Metrics = terminal.ProvenanceReal
+	return nil
+}
+
+// matchTerminal записывает в atm terminal_id ближайшего активного терминала
+func (r *TerminalRepository) matchTerminal(ctx context.Context, atm *terminal.ATM) error {
+	query := `
+		SELECT terminal_id
+		FROM terminals
+		WHERE is_active
+		  AND location IS NOT NULL
+		  AND ST_DWithin(location::geography, ST_SetSRID(ST_Point($1, $2), 4326)::geography, $3)
+		ORDER BY location <-> ST_SetSRID(ST_Point($1, $2), 4326)
+		LIMIT 1
+	`
+	err := r.db.QueryRowContext(ctx, query, atm.Lng, atm.Lat, r.bank.MatchRadiusM).Scan(&atm.TerminalID)
+	if err == sql.ErrNoRows {
+		return terminal.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка поиска терминала: %w", err)
+	}
+	return nil
+}
+
+// EnrichCompetitor оценивает потоки конкурента по средним показателям нашей сети:
+// внутренней статистики конкурентов у нас нет
+func (r *TerminalRepository) EnrichCompetitor(ctx context.Context, atm *terminal.ATM) error {
+	atm.IsForte = false
+
+	query := `
+		SELECT
+			COALESCE(AVG(total_withdrawal_amount), 0),
+			COALESCE(AVG(total_deposit_amount), 0)
+		FROM daily_stats
+		WHERE report_date >= $1
+	`
+	since := time.Now().AddDate(0, 0, -statsWindowDays)
+	err := r.db.QueryRowContext(ctx, query, since).Scan(&atm.EstWithdrawalKZT, &atm.EstDepositKZT)
+	if err != nil {
+		return fmt.Errorf("ошибка оценки потоков конкурента: %w", err)
+	}
+	atm.Provenance.Metrics = terminal.ProvenanceEstimated
+	return nil
+}
+
+// GenerateRandomCompetitors в реальном режиме ничего не выдумывает
+func (r *TerminalRepository) GenerateRandomCompetitors(c city.City, count int) []terminal.ATM {
+	return nil
+}
+
+// loadDailyStats - средняя частота снятий в день
+func (r *TerminalRepository) loadDailyStats(ctx context.Context, atm *terminal.ATM, since time.Time) error {
+	query := `
+		SELECT COALESCE(ROUND(AVG(transaction_count)), 0)
+		FROM daily_stats
+		WHERE terminal_id = $1 AND report_date >= $2
+	`
+	if err := r.db.QueryRowContext(ctx, query, atm.TerminalID, since).Scan(&atm.WithdrawalFreqPerDay); err != nil {
+		return fmt.Errorf("ошибка чтения daily_stats: %w", err)
+	}
+	return nil
+}
+
+// loadCassettes - последний замер по каждой кассете и средний остаток за окно
+func (r *TerminalRepository) loadCassettes(ctx context.Context, atm *terminal.ATM, since time.Time) error {
+	query := `
+		SELECT DISTINCT ON (cassette_type)
+			cassette_type, currency, current_balance, max_capacity
+		FROM cash_levels
+		WHERE terminal_id = $1
+		ORDER BY cassette_type DESC, check_time DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, atm.TerminalID)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения cash_levels: %w", err)
+	}
+	defer rows.Close()
+
+	atm.Cassettes = nil
+	atm.TotalCashKZT = 0
+	for rows.Next() {
+		var c terminal.Cassette
+		if err := rows.Scan(&c.Type, &c.Currency, &c.Amount, &c.Capacity); err != nil {
+			return fmt.Errorf("ошибка чтения кассеты: %w", err)
+		}
+		c.Status = terminal.CassetteStatus(c.Type, c.Amount, c.Capacity)
+		atm.Cassettes = append(atm.Cassettes, c)
+		atm.TotalCashKZT += c.Amount
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения cash_levels: %w", err)
+	}
+
+	avgQuery := `
+		SELECT COALESCE(AVG(current_balance), 0)
+		FROM cash_levels
+		WHERE terminal_id = $1 AND cassette_type = $2 AND check_time >= $3
+	`
+	err = r.db.QueryRowContext(ctx, avgQuery, atm.TerminalID, terminal.CassetteCashOut, since).Scan(&atm.AvgCashBalanceKZT)
+	if err != nil {
+		return fmt.Errorf("ошибка расчета среднего остатка: %w", err)
+	}
+	return nil
+}
+
+// loadDowntime - доля простоя за окно по логам обслуживания (0..1)
+func (r *TerminalRepository) loadDowntime(ctx context.Context, atm *terminal.ATM, since time.Time) error {
+	// Незакрытые ремонты считаем до текущего момента, а начатые до окна - с начала окна
+	query := `
+		SELECT COALESCE(SUM(
+			EXTRACT(EPOCH FROM (LEAST(COALESCE(end_time, NOW()), NOW()) - GREATEST(start_time, $2)))
+		), 0) / EXTRACT(EPOCH FROM (NOW() - $2))
+		FROM maintenance_logs
+		WHERE terminal_id = $1
+		  AND COALESCE(end_time, NOW()) > $2
+	`
+	if err := r.db.QueryRowContext(ctx, query, atm.TerminalID, since).Scan(&atm.DowntimePct); err != nil {
+		return fmt.Errorf("ошибка расчета простоя: %w", err)
+	}
+	return nil
+}
+
+// loadComplaints - открытые жалобы клиентов
+func (r *TerminalRepository) loadComplaints(ctx context.Context, atm *terminal.ATM) error {
+	query := `
+		SELECT id, COALESCE(complaint_category, ''), COALESCE(complaint_text, ''), created_at, status
+		FROM client_complaints
+		WHERE terminal_id = $1 AND status = 'OPEN'
+		ORDER BY created_at DESC
+		LIMIT 20
+	`
+	rows, err := r.db.QueryContext(ctx, query, atm.TerminalID)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения жалоб: %w", err)
+	}
+	defer rows.Close()
+
+	atm.Complaints = nil
+	for rows.Next() {
+		var c terminal.Complaint
+		var createdAt time.Time
+		if err := rows.Scan(&c.ID, &c.Category, &c.Text, &createdAt, &c.Status); err != nil {
+			return fmt.Errorf("ошибка чтения жалобы: %w", err)
+		}
+		c.Date = createdAt.Format("2006-01-02")
+		atm.Complaints = append(atm.Complaints, c)
+	}
+	return rows.Err()
+}
@@ -4,78 +4,280 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"geocash/internal/config"
 	"geocash/internal/domain/traffic"
+	"geocash/internal/geo"
+	"io"
 	"log"
-	"strings"
+
+	"github.com/lib/pq"
 )
 
+// Размер пачки COPY по умолчанию
+const defaultCopyBatchSize = 5000
+
 type TrafficIntegrator struct {
-	db *sql.DB
+	db          *sql.DB
+	batchSize   int
+	scoreMethod string
 }
 
-func NewTrafficIntegrator(db *sql.DB) *TrafficIntegrator {
-	return &TrafficIntegrator{db: db}
+func NewTrafficIntegrator(db *sql.DB, cfg config.TrafficConfig) *TrafficIntegrator {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultCopyBatchSize
+	}
+	return &TrafficIntegrator{db: db, batchSize: batchSize, scoreMethod: cfg.ScoreMethod}
 }
 
-// EnrichZonesWithTraffic обновляет traffic_score в таблице geo_traffic_zones
-func (t *TrafficIntegrator) EnrichZonesWithTraffic(ctx context.Context, segments []traffic.TrafficSegment) error {
-	if len(segments) == 0 {
-		return nil
-	}
+// ImportTraffic читает сегменты из src потоком, заливает их во временную
+// таблицу через COPY пачками по batchSize и обновляет traffic_score в
+// geo_traffic_zones вместе с профилями будни/выходные по часам.
+// Файл заменяет трафик городов (areas), которых он коснулся: прошлый трафик их
+// зон сбрасывается, и шкала traffic_score строится только по этому импорту.
+// Весь импорт идет в одной транзакции.
+func (t *TrafficIntegrator) ImportTraffic(ctx context.Context, src traffic.SegmentSource, areas []geo.Bounds) (traffic.ImportStats, error) {
+	var stats traffic.ImportStats
 
 	tx, err := t.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return stats, err
 	}
 	defer tx.Rollback()
 
 	// 1. Создаем временную таблицу
-	_, err = tx.ExecContext(ctx, `CREATE TEMP TABLE temp_csv_traffic (traffic INT, geom TEXT) ON COMMIT DROP;`)
+	_, err = tx.ExecContext(ctx, `
+		CREATE TEMP TABLE temp_csv_traffic (
+			edge_id BIGINT,
+			traffic INT,
+			weekend_traffic INT,
+			weekday_hourly DOUBLE PRECISION[],
+			weekend_hourly DOUBLE PRECISION[],
+			geom TEXT
+		) ON COMMIT DROP;`)
 	if err != nil {
-		return fmt.Errorf("ошибка создания temp таблицы: %w", err)
+		return stats, fmt.Errorf("ошибка создания temp таблицы: %w", err)
 	}
 
-	// 2. Заливаем данные (Batch Insert)
-	// Берем первые 2000 для примера, чтобы не забить память
-	limit := 2000
-	if len(segments) < limit {
-		limit = len(segments)
+	// 2. Заливаем данные через COPY пачками, чтобы не держать файл в памяти
+	loaded, err := t.copySegments(ctx, tx, src)
+	stats.SegmentsLoaded = loaded
+	if err != nil {
+		return stats, err
+	}
+	if loaded == 0 {
+		return stats, tx.Commit()
 	}
 
-	valueStrings := make([]string, 0, limit)
-	valueArgs := make([]interface{}, 0, limit*2)
-
-	for i := 0; i < limit; i++ {
-		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d)", i*2+1, i*2+2))
-		valueArgs = append(valueArgs, segments[i].WeekdayTraffic, segments[i].Geometry)
+	// 3. Один раз переводим WKT в геометрию и строим индекс для пересечений
+	_, err = tx.ExecContext(ctx, `
+		ALTER TABLE temp_csv_traffic ADD COLUMN g geometry(Geometry, 4326);
+		UPDATE temp_csv_traffic SET g = ST_GeomFromText(geom, 4326);
+		CREATE INDEX ON temp_csv_traffic USING GIST (g);
+		ANALYZE temp_csv_traffic;
+	`)
+	if err != nil {
+		return stats, fmt.Errorf("ошибка подготовки геометрии: %w", err)
 	}
 
-	stmt := fmt.Sprintf("INSERT INTO temp_csv_traffic (traffic, geom) VALUES %s", strings.Join(valueStrings, ","))
-	_, err = tx.ExecContext(ctx, stmt, valueArgs...)
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM temp_csv_traffic t
+		WHERE EXISTS (SELECT 1 FROM geo_traffic_zones z WHERE ST_Intersects(z.area_polygon, t.g))
+	`).Scan(&stats.SegmentsMatched)
 	if err != nil {
-		return fmt.Errorf("ошибка вставки batch: %w", err)
+		return stats, fmt.Errorf("ошибка подсчета сегментов в зонах: %w", err)
+	}
+
+	// 4. Сбрасываем прошлый трафик зон в городах, которых коснулся файл
+	if err := t.resetZones(ctx, tx, areas); err != nil {
+		return stats, err
 	}
 
-	// 3. Обновляем зоны через пересечение (ST_Intersects)
+	// 5. Обновляем зоны через пересечение (ST_Intersects).
+	// traffic_raw взвешивает трафик длиной сегмента внутри зоны (в метрах),
+	// почасовые профили сегментов складываются поэлементно.
 	query := `
-		UPDATE geo_traffic_zones z
-		SET traffic_score = sub.total_traffic / 100
-		FROM (
-			SELECT z.id, SUM(t.traffic) as total_traffic
+		WITH zone_segments AS (
+			SELECT z.id AS zone_id, t.*,
+				ST_Length(ST_Intersection(z.area_polygon, t.g)::geography) AS length_m
 			FROM geo_traffic_zones z
-			JOIN temp_csv_traffic t 
-			ON ST_Intersects(z.area_polygon, ST_GeomFromText(t.geom, 4326))
-			GROUP BY z.id
-		) sub
-		WHERE z.id = sub.id;
+			JOIN temp_csv_traffic t ON ST_Intersects(z.area_polygon, t.g)
+		),
+		totals AS (
+			SELECT zone_id,
+				SUM(traffic) AS weekday_total,
+				SUM(weekend_traffic) AS weekend_total,
+				SUM(traffic * length_m) AS raw_total
+			FROM zone_segments
+			GROUP BY zone_id
+		),
+		hourly AS (
+			SELECT zone_id,
+				array_agg(wd ORDER BY hour) AS weekday_hourly,
+				array_agg(we ORDER BY hour) AS weekend_hourly
+			FROM (
+				SELECT s.zone_id, h.hour, SUM(h.wd) AS wd, SUM(h.we) AS we
+				FROM zone_segments s
+				CROSS JOIN LATERAL unnest(s.weekday_hourly, s.weekend_hourly) WITH ORDINALITY AS h(wd, we, hour)
+				GROUP BY s.zone_id, h.hour
+			) per_hour
+			GROUP BY zone_id
+		),
+		updated AS (
+			UPDATE geo_traffic_zones z
+			SET traffic_raw = totals.raw_total,
+				weekday_traffic = totals.weekday_total,
+				weekend_traffic = totals.weekend_total,
+				weekday_hourly = hourly.weekday_hourly,
+				weekend_hourly = hourly.weekend_hourly
+			FROM totals
+			JOIN hourly USING (zone_id)
+			WHERE z.id = totals.zone_id
+			RETURNING z.id
+		)
+		INSERT INTO temp_imported_zones SELECT id FROM updated;
 	`
+	_, err = tx.ExecContext(ctx, `CREATE TEMP TABLE temp_imported_zones (id INT PRIMARY KEY) ON COMMIT DROP;`)
+	if err != nil {
+		return stats, fmt.Errorf("ошибка создания temp таблицы: %w", err)
+	}
 	res, err := tx.ExecContext(ctx, query)
 	if err != nil {
-		return fmt.Errorf("ошибка update: %w", err)
+		return stats, fmt.Errorf("ошибка update: %w", err)
 	}
 
 	count, _ := res.RowsAffected()
+	stats.ZonesUpdated = int(count)
 	log.Printf("✅ Обновлено зон трафика: %d", count)
 
-	return tx.Commit()
+	// 6. Пересчитываем traffic_score зон этого импорта: шкала не зависит от прошлых файлов
+	if err := t.normalizeScores(ctx, tx); err != nil {
+		return stats, err
+	}
+
+	return stats, tx.Commit()
+}
+
+// resetZones сбрасывает трафик и traffic_score зон в границах городов, которых
+// коснулся хоть один сегмент файла. Зоны без traffic_raw (заведены вручную) не трогаются.
+func (t *TrafficIntegrator) resetZones(ctx context.Context, tx *sql.Tx, areas []geo.Bounds) error {
+	minLng := make(pq.Float64Array, len(areas))
+	minLat := make(pq.Float64Array, len(areas))
+	maxLng := make(pq.Float64Array, len(areas))
+	maxLat := make(pq.Float64Array, len(areas))
+	for i, a := range areas {
+		minLng[i], minLat[i], maxLng[i], maxLat[i] = a.MinLng, a.MinLat, a.MaxLng, a.MaxLat
+	}
+
+	query := `
+		WITH touched AS (
+			SELECT a.env
+			FROM (
+				SELECT ST_MakeEnvelope(x1, y1, x2, y2, 4326) AS env
+				FROM unnest($1::float8[], $2::float8[], $3::float8[], $4::float8[]) AS b(x1, y1, x2, y2)
+			) a
+			WHERE EXISTS (SELECT 1 FROM temp_csv_traffic t WHERE t.g && a.env)
+		)
+		UPDATE geo_traffic_zones z
+		SET traffic_raw = NULL, traffic_score = NULL,
+			weekday_traffic = NULL, weekend_traffic = NULL,
+			weekday_hourly = NULL, weekend_hourly = NULL
+		WHERE z.traffic_raw IS NOT NULL
+		AND EXISTS (SELECT 1 FROM touched WHERE z.area_polygon && touched.env)
+	`
+	res, err := tx.ExecContext(ctx, query, minLng, minLat, maxLng, maxLat)
+	if err != nil {
+		return fmt.Errorf("ошибка сброса прошлого трафика: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("🧹 Сброшен прошлый трафик зон: %d", n)
+	}
+	return nil
+}
+
+// normalizeScores переводит traffic_raw зон этого импорта (temp_imported_zones)
+// в traffic_score 1..100.
+func (t *TrafficIntegrator) normalizeScores(ctx context.Context, tx *sql.Tx) error {
+	var score string
+	switch t.scoreMethod {
+	case config.ScoreLinear:
+		score = `traffic_raw / NULLIF(MAX(traffic_raw) OVER (), 0)`
+	case config.ScoreLog:
+		score = `LN(1 + traffic_raw) / NULLIF(LN(1 + MAX(traffic_raw) OVER ()), 0)`
+	default:
+		// Единственная зона с трафиком - самая оживленная, а не самая тихая
+		score = `CASE WHEN COUNT(*) OVER () = 1 THEN 1 ELSE percent_rank() OVER (ORDER BY traffic_raw) END`
+	}
+
+	query := `
+		UPDATE geo_traffic_zones z
+		SET traffic_score = 1 + ROUND(99 * COALESCE(s.score, 0))
+		FROM (
+			SELECT id, ` + score + ` AS score
+			FROM geo_traffic_zones
+			WHERE id IN (SELECT id FROM temp_imported_zones)
+		) s
+		WHERE z.id = s.id
+	`
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("ошибка нормализации traffic_score: %w", err)
+	}
+	return nil
+}
+
+// copySegments заливает сегменты во временную таблицу через COPY.
+// Каждая пачка - отдельный COPY, поэтому буфер драйвера не растет с размером файла.
+func (t *TrafficIntegrator) copySegments(ctx context.Context, tx *sql.Tx, src traffic.SegmentSource) (int, error) {
+	loaded := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return loaded, err
+		}
+
+		n, err := t.copyBatch(ctx, tx, src)
+		loaded += n
+		if err == io.EOF {
+			return loaded, nil
+		}
+		if err != nil {
+			return loaded, err
+		}
+	}
+}
+
+// copyBatch копирует до batchSize сегментов. io.EOF - источник исчерпан.
+func (t *TrafficIntegrator) copyBatch(ctx context.Context, tx *sql.Tx, src traffic.SegmentSource) (int, error) {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("temp_csv_traffic",
+		"edge_id", "traffic", "weekend_traffic", "weekday_hourly", "weekend_hourly", "geom"))
+	if err != nil {
+		return 0, fmt.Errorf("ошибка запуска COPY: %w", err)
+	}
+	defer stmt.Close()
+
+	n := 0
+	var srcErr error
+	for n < t.batchSize {
+		seg, err := src.Next()
+		if err != nil {
+			srcErr = err
+			break
+		}
+		// В БД кладем абсолютный трафик по часам, чтобы профили зон складывались суммой
+		weekdayHourly := pq.Float64Array(traffic.HourlyTraffic(seg.WeekdayTraffic, seg.WeekdayHourly))
+		weekendHourly := pq.Float64Array(traffic.HourlyTraffic(seg.WeekendTraffic, seg.WeekendHourly))
+		if _, err := stmt.ExecContext(ctx, seg.EdgeID, seg.WeekdayTraffic, seg.WeekendTraffic, weekdayHourly, weekendHourly, seg.Geometry); err != nil {
+			return n, fmt.Errorf("ошибка COPY: %w", err)
+		}
+		n++
+	}
+	if srcErr != nil && srcErr != io.EOF {
+		return n, fmt.Errorf("ошибка чтения сегментов: %w", srcErr)
+	}
+
+	// Пустой Exec завершает COPY и отправляет пачку на сервер
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return n, fmt.Errorf("ошибка завершения COPY: %w", err)
+	}
+	return n, srcErr
 }
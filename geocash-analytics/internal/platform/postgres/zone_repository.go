@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"geocash/internal/analytics"
+	"geocash/internal/domain/traffic"
+	"geocash/internal/geo"
+
+	"github.com/lib/pq"
+)
+
+// ZoneRepository реализует traffic.ZoneRepository поверх geo_traffic_zones
+type ZoneRepository struct {
+	db *sql.DB
+}
+
+func NewZoneRepository(db *sql.DB) *ZoneRepository {
+	return &ZoneRepository{db: db}
+}
+
+// sliceTrafficExpr - трафик зоны z в срезе ($1 - тип дня, $2/$3 - часы), см. миграцию 000005
+const sliceTrafficExpr = `traffic_slice(z.weekday_hourly, z.weekday_traffic, z.weekend_hourly, z.weekend_traffic, $1, $2, $3)`
+
+// ZonesTraffic возвращает зоны, для которых уже загружен трафик
+func (r *ZoneRepository) ZonesTraffic(ctx context.Context, area geo.Bounds, slice traffic.TimeSlice) ([]traffic.ZoneTraffic, error) {
+	query := `
+		SELECT z.id, COALESCE(z.zone_code, ''), COALESCE(z.zone_name, ''), ST_AsGeoJSON(z.area_polygon), COALESCE(z.traffic_score, 0),
+			` + sliceTrafficExpr + `
+		FROM geo_traffic_zones z
+		WHERE z.weekday_traffic IS NOT NULL AND z.area_polygon IS NOT NULL
+		AND z.area_polygon && ST_MakeEnvelope($4, $5, $6, $7, 4326)
+	`
+	return r.query(ctx, query, slice.Day, slice.FromHour, slice.ToHour, area.MinLng, area.MinLat, area.MaxLng, area.MaxLat)
+}
+
+// ExpansionCandidates - зоны без наших терминалов, отсортированные по трафику в срезе.
+// Тот же отбор, что во view_expansion_recommendations, но ранжирование по срезу.
+func (r *ZoneRepository) ExpansionCandidates(ctx context.Context, area geo.Bounds, slice traffic.TimeSlice, closed []string, limit int) ([]traffic.ZoneTraffic, error) {
+	query := `
+		SELECT z.id, COALESCE(z.zone_code, ''), COALESCE(z.zone_name, ''), ST_AsGeoJSON(z.area_polygon), COALESCE(z.traffic_score, 0),
+			` + sliceTrafficExpr + ` AS slice_traffic
+		FROM geo_traffic_zones z
+		WHERE z.area_polygon IS NOT NULL
+		AND z.area_polygon && ST_MakeEnvelope($5, $6, $7, $8, 4326)
+		AND NOT EXISTS (
+			SELECT 1 FROM terminals t
+			WHERE ST_Contains(z.area_polygon, t.location)
+			AND t.terminal_id <> ALL(COALESCE($9::text[], '{}'))
+		)
+		ORDER BY slice_traffic DESC, z.traffic_score DESC NULLS LAST
+		LIMIT $4
+	`
+	return r.query(ctx, query, slice.Day, slice.FromHour, slice.ToHour, limit,
+		area.MinLng, area.MinLat, area.MaxLng, area.MaxLat, pq.Array(closed))
+}
+
+func (r *ZoneRepository) query(ctx context.Context, query string, args ...interface{}) ([]traffic.ZoneTraffic, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения зон трафика: %w", err)
+	}
+	defer rows.Close()
+
+	zones := []traffic.ZoneTraffic{}
+	for rows.Next() {
+		var z traffic.ZoneTraffic
+		var geojson string
+		if err := rows.Scan(&z.ID, &z.Code, &z.Name, &geojson, &z.TrafficScore, &z.Traffic); err != nil {
+			return nil, fmt.Errorf("ошибка чтения зон трафика: %w", err)
+		}
+
+		var poly struct {
+			Coordinates [][][]float64 `json:"coordinates"`
+		}
+		if err := json.Unmarshal([]byte(geojson), &poly); err != nil || len(poly.Coordinates) == 0 {
+			return nil, fmt.Errorf("зона %d: некорректный полигон", z.ID)
+		}
+		z.Polygon = poly.Coordinates[0]
+		zones = append(zones, z)
+	}
+	return zones, rows.Err()
+}
+
+// Источник зон, созданных из гексагональной сетки
+const gridZoneSource = "GRID"
+
+// GridSyncStats - итог синхронизации зон с сеткой
+type GridSyncStats struct {
+	Inserted int
+	Updated  int // полигон изменился, трафик ячейки сброшен
+	Removed  int
+}
+
+// SyncGrid записывает ячейки сетки в geo_traffic_zones по zone_code:
+// новые добавляет, у существующих с другим полигоном (сменились cell_radius или aspect)
+// обновляет полигон и сбрасывает трафик - он считался для прежней формы ячейки.
+// prune - удалить зоны сетки, которых больше нет (после смены bbox или cell_radius).
+func (r *ZoneRepository) SyncGrid(ctx context.Context, cells []analytics.HexCell, prune bool) (GridSyncStats, error) {
+	var stats GridSyncStats
+
+	codes := make([]string, len(cells))
+	polygons := make([]string, len(cells))
+	for i, c := range cells {
+		codes[i] = c.Code
+		polygons[i] = ringWKT(c.Polygon)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return stats, err
+	}
+	defer tx.Rollback()
+
+	// xmax = 0 у только что вставленной строки, иначе строка обновлена;
+	// ячейки с прежним полигоном не обновляются и не возвращаются
+	rows, err := tx.QueryContext(ctx, `
+		INSERT INTO geo_traffic_zones (zone_code, zone_name, area_polygon, source_data)
+		SELECT code, code, ST_GeomFromText(wkt, 4326), $3
+		FROM unnest($1::text[], $2::text[]) AS c(code, wkt)
+		ON CONFLICT (zone_code) DO UPDATE SET area_polygon = EXCLUDED.area_polygon,
+			traffic_raw = NULL, traffic_score = NULL,
+			weekday_traffic = NULL, weekend_traffic = NULL,
+			weekday_hourly = NULL, weekend_hourly = NULL
+		WHERE geo_traffic_zones.area_polygon IS NULL
+			OR NOT ST_Equals(geo_traffic_zones.area_polygon, EXCLUDED.area_polygon)
+		RETURNING (xmax = 0)
+	`, pq.Array(codes), pq.Array(polygons), gridZoneSource)
+	if err != nil {
+		return stats, fmt.Errorf("ошибка записи зон сетки: %w", err)
+	}
+	for rows.Next() {
+		var inserted bool
+		if err := rows.Scan(&inserted); err != nil {
+			rows.Close()
+			return stats, err
+		}
+		if inserted {
+			stats.Inserted++
+		} else {
+			stats.Updated++
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("ошибка записи зон сетки: %w", err)
+	}
+
+	if prune {
+		res, err := tx.ExecContext(ctx, `
+			DELETE FROM geo_traffic_zones
+			WHERE source_data = $1 AND zone_code <> ALL($2::text[])
+		`, gridZoneSource, pq.Array(codes))
+		if err != nil {
+			return stats, fmt.Errorf("ошибка удаления устаревших зон: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		stats.Removed = int(n)
+	}
+
+	return stats, tx.Commit()
+}
+
+// ringWKT - POLYGON из замкнутого кольца [lng, lat]
+func ringWKT(ring [][]float64) string {
+	var b strings.Builder
+	b.WriteString("POLYGON((")
+	for i, p := range ring {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%.7f %.7f", p[0], p[1])
+	}
+	b.WriteString("))")
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"sort"
+	"strings"
+
+	"geocash/internal/domain/terminal"
+	"geocash/internal/openinghours"
+)
+
+// applyTags переносит в банкомат атрибуты из тегов OSM. Незнакомые значения
+// пропускаются: лучше "неизвестно", чем неверное "нет".
+func applyTags(atm *terminal.ATM, tags map[string]string) {
+	if oh := strings.TrimSpace(tags["opening_hours"]); oh != "" {
+		atm.OpeningHours = oh
+		if schedule, err := openinghours.Parse(oh); err == nil {
+			atm.Open24x7 = ptr(schedule.AlwaysOpen())
+		}
+	}
+	atm.CashIn = yesNo(tags["cash_in"])
+	atm.Indoor = indoor(tags["indoor"])
+	atm.Level = strings.TrimSpace(tags["level"])
+
+	switch w := strings.ToLower(strings.TrimSpace(tags["wheelchair"])); w {
+	case terminal.AccessYes, terminal.AccessLimited, terminal.AccessNo:
+		atm.Wheelchair = w
+	}
+
+	for key, val := range tags {
+		code, ok := strings.CutPrefix(key, "currency:")
+		if !ok || !isCurrencyCode(code) {
+			continue
+		}
+		if v := yesNo(val); v != nil && *v {
+			atm.Currencies = append(atm.Currencies, code)
+		}
+	}
+	sort.Strings(atm.Currencies)
+}
+
+// yesNo - yes/no тега; прочие значения - неизвестно
+func yesNo(v string) *bool {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "yes":
+		return ptr(true)
+	case "no":
+		return ptr(false)
+	}
+	return nil
+}
+
+// indoor - кроме yes/no OSM допускает тип помещения: room, area, corridor
+func indoor(v string) *bool {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "room", "area", "corridor":
+		return ptr(true)
+	}
+	return yesNo(v)
+}
+
+// isCurrencyCode - код ISO 4217: KZT, USD (не currency:others)
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"geocash/internal/domain/brand"
+	"geocash/internal/domain/terminal"
+	"geocash/internal/geo"
+)
+
+// Типы объектов OSM
+const (
+	osmNode     = "node"
+	osmWay      = "way"
+	osmRelation = "relation"
+)
+
+// osmElement - банкомат или отделение из OSM; для way/relation координаты - центр
+type osmElement struct {
+	Type string
+	ID   int64
+	Lat  float64
+	Lon  float64
+	Tags map[string]string
+
+	// Банк по справочнику (заполняет identify); пусто - не опознан
+	BankID   string
+	BankName string
+}
+
+// OSMID - "node/123", "way/456": id уникальны только в пределах типа
+func (e osmElement) OSMID() string {
+	return fmt.Sprintf("%s/%d", e.Type, e.ID)
+}
+
+// bank - банк: из справочника, иначе по тегам brand, operator, name
+func (e osmElement) bank() string {
+	if e.BankName != "" {
+		return e.BankName
+	}
+	for _, key := range []string{"brand", "operator", "name"} {
+		if val, ok := e.Tags[key]; ok {
+			return val
+		}
+	}
+	return "Unknown"
+}
+
+// identify сопоставляет объекты со справочником банков
+func identify(elements []osmElement, brands *brand.Dictionary) []osmElement {
+	for i := range elements {
+		if m, ok := brands.MatchTags(elements[i].Tags); ok {
+			elements[i].BankID, elements[i].BankName = m.BankID, m.Name
+		}
+	}
+	return elements
+}
+
+func typeRank(t string) int {
+	switch t {
+	case osmNode:
+		return 0
+	case osmWay:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// dedupe убирает дубли: отделение контуром здания (way) и банкомат точкой (node)
+// на одном месте - один терминал. Точки остаются всегда (два банкомата одного банка
+// рядом - обычное дело), а way/relation отбрасывается, если в радиусе radiusM уже
+// есть объект того же банка. Точки точнее центра здания, поэтому они в приоритете.
+func dedupe(elements []osmElement, radiusM float64) []osmElement {
+	sort.SliceStable(elements, func(i, j int) bool {
+		return typeRank(elements[i].Type) < typeRank(elements[j].Type)
+	})
+
+	kept := make([]osmElement, 0, len(elements))
+	for _, e := range elements {
+		if e.Type != osmNode && hasTwin(kept, e, radiusM) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// hasTwin - есть ли среди kept объект того же банка ближе radiusM
+func hasTwin(kept []osmElement, e osmElement, radiusM float64) bool {
+	p := geo.Point{Lat: e.Lat, Lng: e.Lon}
+	bank := e.bank()
+	for _, k := range kept {
+		if geo.Distance(p, geo.Point{Lat: k.Lat, Lng: k.Lon}) > radiusM {
+			continue
+		}
+		// Без бренда не знаем, чей объект - считаем тем же
+		other := k.bank()
+		if bank == "Unknown" || other == "Unknown" || sameBank(e, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameBank - по id справочника, а для неопознанных - по названию
+func sameBank(a, b osmElement) bool {
+	if a.BankID != "" || b.BankID != "" {
+		return a.BankID == b.BankID
+	}
+	return strings.EqualFold(a.bank(), b.bank())
+}
+
+// toATMs переводит объекты OSM в терминалы
+func toATMs(elements []osmElement) []terminal.ATM {
+	atms := make([]terminal.ATM, 0, len(elements))
+	for _, e := range elements {
+		bank := e.bank()
+		name := bank + " ATM"
+		if val, ok := e.Tags["name"]; ok {
+			name = val
+		}
+		atm := terminal.ATM{
+			ID: "osm:" + e.OSMID(), OSMID: e.OSMID(), Name: name, Lat: e.Lat, Lng: e.Lon, Bank: bank, BankID: e.BankID,
+		}
+		applyTags(&atm, e.Tags)
+		atms = append(atms, atm)
+	}
+	return atms
+}
@@ -0,0 +1,263 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"geocash/internal/config"
+	"geocash/internal/domain/brand"
+	"geocash/internal/domain/city"
+	"geocash/internal/domain/terminal"
+	"geocash/internal/geo"
+
+	"github.com/paulmach/osm"
+	"github.com/paulmach/osm/osmpbf"
+	"github.com/paulmach/osm/osmxml"
+)
+
+// FileProvider читает банкоматы и отделения из локальной выгрузки OSM
+// (.osm XML или .osm.pbf, например kazakhstan-latest.osm.pbf с Geofabrik).
+// Нужен, когда Overpass недоступен: закрытый контур, демо без интернета.
+//
+// Файл сканируется один раз (и заново, если он изменился), в памяти остаются
+// только банкоматы и банки всей выгрузки; города вырезаются по bbox.
+type FileProvider struct {
+	cfg    config.OSMConfig
+	brands *brand.Dictionary
+
+	mu       sync.Mutex
+	modTime  time.Time
+	elements []osmElement
+}
+
+func NewFileProvider(cfg config.OSMConfig, brands *brand.Dictionary) *FileProvider {
+	return &FileProvider{cfg: cfg, brands: brands}
+}
+
+func (p *FileProvider) Name() string { return config.ATMSourceOSM }
+
+// FetchATMs - банкоматы и отделения из выгрузки в границах города
+func (p *FileProvider) FetchATMs(ctx context.Context, c city.City) ([]terminal.ATM, error) {
+	elements, err := p.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := c.Bounds()
+	var inCity []osmElement
+	for _, e := range elements {
+		if bounds.Contains(geo.Point{Lat: e.Lat, Lng: e.Lon}) {
+			inCity = append(inCity, e)
+		}
+	}
+	return toATMs(dedupe(identify(inCity, p.brands), p.cfg.DedupRadiusM)), nil
+}
+
+// load отдает объекты из кэша или сканирует файл, если он изменился
+func (p *FileProvider) load(ctx context.Context) ([]osmElement, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, err := os.Stat(p.cfg.FilePath)
+	if err != nil {
+		return nil, terminal.NewSourceError(p.Name(), terminal.ErrSourceUnavailable, err)
+	}
+	if p.elements != nil && info.ModTime().Equal(p.modTime) {
+		return p.elements, nil
+	}
+
+	start := time.Now()
+	elements, err := scanExtract(ctx, p.cfg.FilePath)
+	if err != nil {
+		return nil, terminal.NewSourceError(p.Name(), terminal.ErrSourceBadData,
+			fmt.Errorf("ошибка чтения выгрузки %s: %w", p.cfg.FilePath, err))
+	}
+	fmt.Printf("🗺️ Выгрузка OSM %s: %d банкоматов и отделений (%s)\n",
+		p.cfg.FilePath, len(elements), time.Since(start).Round(time.Millisecond))
+
+	p.elements, p.modTime = elements, info.ModTime()
+	return elements, nil
+}
+
+// extractScan - что нужно собрать из выгрузки. В файле way хранит только id точек,
+// а relation - id участников, поэтому координаты добираются отдельными проходами:
+//  1. банкоматы/банки всех типов, id точек их way и участников их relation;
+//  2. точки way, входящих в эти relation;
+//  3. координаты всех нужных точек.
+type extractScan struct {
+	nodes     []osmElement
+	ways      []taggedWay
+	relations []taggedRelation
+
+	memberWays map[osm.WayID][]osm.NodeID // way - участник relation -> его точки
+	coords     map[osm.NodeID]geo.Point   // нужные точки -> координаты
+}
+
+type taggedWay struct {
+	osmElement
+	nodes []osm.NodeID
+}
+
+type taggedRelation struct {
+	osmElement
+	nodes []osm.NodeID
+	ways  []osm.WayID
+}
+
+func scanExtract(ctx context.Context, path string) ([]osmElement, error) {
+	s := extractScan{memberWays: make(map[osm.WayID][]osm.NodeID), coords: make(map[osm.NodeID]geo.Point)}
+
+	// 1. Объекты с нужными тегами
+	err := scanFile(ctx, path, func(sc *osmpbf.Scanner) {}, func(o osm.Object) {
+		switch o := o.(type) {
+		case *osm.Node:
+			if isATMOrBank(o.Tags) {
+				s.nodes = append(s.nodes, osmElement{Type: osmNode, ID: int64(o.ID), Lat: o.Lat, Lon: o.Lon, Tags: o.Tags.Map()})
+			}
+		case *osm.Way:
+			if isATMOrBank(o.Tags) {
+				w := taggedWay{osmElement: osmElement{Type: osmWay, ID: int64(o.ID), Tags: o.Tags.Map()}}
+				for _, n := range o.Nodes {
+					w.nodes = append(w.nodes, n.ID)
+					s.coords[n.ID] = geo.Point{}
+				}
+				s.ways = append(s.ways, w)
+			}
+		case *osm.Relation:
+			if isATMOrBank(o.Tags) {
+				r := taggedRelation{osmElement: osmElement{Type: osmRelation, ID: int64(o.ID), Tags: o.Tags.Map()}}
+				for _, m := range o.Members {
+					switch m.Type {
+					case osm.TypeNode:
+						r.nodes = append(r.nodes, osm.NodeID(m.Ref))
+						s.coords[osm.NodeID(m.Ref)] = geo.Point{}
+					case osm.TypeWay:
+						r.ways = append(r.ways, osm.WayID(m.Ref))
+						s.memberWays[osm.WayID(m.Ref)] = nil
+					}
+				}
+				s.relations = append(s.relations, r)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Точки way - участников relation
+	if len(s.memberWays) > 0 {
+		err = scanFile(ctx, path, func(sc *osmpbf.Scanner) { sc.SkipNodes, sc.SkipRelations = true, true }, func(o osm.Object) {
+			w, ok := o.(*osm.Way)
+			if !ok {
+				return
+			}
+			if _, need := s.memberWays[w.ID]; !need {
+				return
+			}
+			refs := make([]osm.NodeID, len(w.Nodes))
+			for i, n := range w.Nodes {
+				refs[i] = n.ID
+				s.coords[n.ID] = geo.Point{}
+			}
+			s.memberWays[w.ID] = refs
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 3. Координаты точек
+	if len(s.coords) > 0 {
+		err = scanFile(ctx, path, func(sc *osmpbf.Scanner) { sc.SkipWays, sc.SkipRelations = true, true }, func(o osm.Object) {
+			if n, ok := o.(*osm.Node); ok {
+				if _, need := s.coords[n.ID]; need {
+					s.coords[n.ID] = geo.Point{Lat: n.Lat, Lng: n.Lon}
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.elements(), nil
+}
+
+// elements - все объекты; для way/relation координаты - центр bbox,
+// как у Overpass `out center`. Объекты без известных точек пропускаются
+// (выгрузка обрезана по границе региона).
+func (s *extractScan) elements() []osmElement {
+	out := append([]osmElement(nil), s.nodes...)
+	for _, w := range s.ways {
+		if e, ok := s.centered(w.osmElement, w.nodes); ok {
+			out = append(out, e)
+		}
+	}
+	for _, r := range s.relations {
+		refs := append([]osm.NodeID(nil), r.nodes...)
+		for _, id := range r.ways {
+			refs = append(refs, s.memberWays[id]...)
+		}
+		if e, ok := s.centered(r.osmElement, refs); ok {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (s *extractScan) centered(e osmElement, refs []osm.NodeID) (osmElement, bool) {
+	var pts []geo.Point
+	for _, id := range refs {
+		if p := s.coords[id]; p != (geo.Point{}) {
+			pts = append(pts, p)
+		}
+	}
+	if len(pts) == 0 {
+		return e, false
+	}
+	b := geo.LineString(pts).Bounds()
+	e.Lat, e.Lon = (b.MinLat+b.MaxLat)/2, (b.MinLng+b.MaxLng)/2
+	return e, true
+}
+
+// scanFile проходит по выгрузке; формат определяется по расширению.
+// skip настраивает пропуск типов в PBF (XML читается целиком).
+func scanFile(ctx context.Context, path string, skip func(*osmpbf.Scanner), visit func(osm.Object)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var scanner osm.Scanner
+	switch {
+	case strings.HasSuffix(path, ".pbf"):
+		sc := osmpbf.New(ctx, f, runtime.GOMAXPROCS(0))
+		skip(sc)
+		scanner = sc
+	case strings.HasSuffix(path, ".osm"), strings.HasSuffix(path, ".xml"):
+		scanner = osmxml.New(ctx, f)
+	default:
+		return fmt.Errorf("неизвестный формат %s: ожидается .osm, .xml или .osm.pbf", path)
+	}
+	defer scanner.Close()
+
+	for scanner.Scan() {
+		visit(scanner.Object())
+	}
+	return scanner.Err()
+}
+
+// isATMOrBank - те же объекты, что запрашиваются у Overpass
+func isATMOrBank(tags osm.Tags) bool {
+	switch tags.Find("amenity") {
+	case "atm", "bank":
+		return true
+	}
+	return false
+}
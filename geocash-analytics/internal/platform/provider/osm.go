@@ -1,76 +1,169 @@
 package provider
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"geocash/internal/config"
+	"geocash/internal/domain/brand"
+	"geocash/internal/domain/city"
 	"geocash/internal/domain/terminal"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"strings"
-	"time"
+	"sync/atomic"
 )
 
+// New создает источник банкоматов OSM по конфигу
+// (osm.source: overpass - OSMProvider, file - FileProvider)
+func New(cfg config.OSMConfig, brands *brand.Dictionary) terminal.Source {
+	if cfg.Source == config.OSMSourceFile {
+		return NewFileProvider(cfg, brands)
+	}
+	return NewOSMProvider(cfg, brands)
+}
+
+// OSMProvider - банкоматы из Overpass API. Основной адрес и зеркала опрашиваются
+// по очереди; следующий запрос начинается с зеркала, ответившего последним.
 type OSMProvider struct {
-	client *http.Client
+	client  *http.Client
+	cfg     config.OSMConfig
+	brands  *brand.Dictionary
+	urls    []string
+	current atomic.Int64 // индекс в urls, ответивший последним
+}
+
+func NewOSMProvider(cfg config.OSMConfig, brands *brand.Dictionary) *OSMProvider {
+	urls := append([]string{cfg.OverpassURL}, cfg.OverpassMirrors...)
+	return &OSMProvider{client: &http.Client{Timeout: cfg.Timeout}, cfg: cfg, brands: brands, urls: urls}
 }
 
-func NewOSMProvider() *OSMProvider {
-	return &OSMProvider{client: &http.Client{Timeout: 10 * time.Second}}
+func (p *OSMProvider) Name() string { return config.ATMSourceOSM }
+
+// overpassResponse - ответ Overpass в формате [out:json]
+type overpassResponse struct {
+	// Remark - Overpass пишет сюда ошибки выполнения (таймаут, нехватка памяти),
+	// при этом отвечает 200 с неполным списком элементов
+	Remark   string `json:"remark"`
+	Elements []struct {
+		Type   string  `json:"type"`
+		ID     int64   `json:"id"`
+		Lat    float64 `json:"lat"`
+		Lon    float64 `json:"lon"`
+		Center *struct {
+			Lat float64 `json:"lat"`
+			Lon float64 `json:"lon"`
+		} `json:"center"`
+		Tags map[string]string `json:"tags"`
+	} `json:"elements"`
 }
 
-func (p *OSMProvider) FetchAllATMs() ([]terminal.ATM, error) {
-	// Запрос к Overpass: дай мне все ATM и Банки в Астане
-	query := `[out:json][timeout:25];
+// FetchATMs - все банкоматы и отделения в границах города.
+// Отделения часто нарисованы контуром здания (way), банкоматы в ТЦ - областью,
+// поэтому запрашиваем nwr, а для way/relation берем центр (out center)
+func (p *OSMProvider) FetchATMs(ctx context.Context, c city.City) ([]terminal.ATM, error) {
+	// Запрос к Overpass: дай мне все ATM и Банки в городе
+	b := overpassBBox(c.BBox)
+	query := fmt.Sprintf(`[out:json][timeout:25];
 		(
-			node["amenity"="atm"](51.05,71.30,51.25,71.55);
-			node["amenity"="bank"](51.05,71.30,51.25,71.55);
+			nwr["amenity"="atm"](%[1]s);
+			nwr["amenity"="bank"](%[1]s);
 		);
-		out body;`
-
-	url := "https://overpass-api.de/api/interpreter"
-	req, _ := http.NewRequest("POST", url, strings.NewReader(query))
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		out center tags;`, b)
 
-	resp, err := p.client.Do(req)
+	osmData, err := p.query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	elements := make([]osmElement, 0, len(osmData.Elements))
+	for _, el := range osmData.Elements {
+		e := osmElement{Type: el.Type, ID: el.ID, Lat: el.Lat, Lon: el.Lon, Tags: el.Tags}
+		if el.Type != osmNode {
+			if el.Center == nil {
+				continue // way без геометрии (например, обрезан bbox)
+			}
+			e.Lat, e.Lon = el.Center.Lat, el.Center.Lon
+		}
+		elements = append(elements, e)
+	}
+
+	// Мы БОЛЬШЕ НЕ пропускаем Forte. Мы берем всех.
+	return toATMs(dedupe(identify(elements, p.brands), p.cfg.DedupRadiusM)), nil
+}
 
-	var osmData struct {
-		Elements []struct {
-			ID   int64             `json:"id"`
-			Lat  float64           `json:"lat"`
-			Lon  float64           `json:"lon"`
-			Tags map[string]string `json:"tags"`
-		} `json:"elements"`
+// query выполняет запрос на основном адресе и зеркалах, пока один из них не ответит.
+// Если не ответил никто, ошибка - ErrSourceBadData, когда хоть один сервер
+// вернул неразборчивые данные, иначе ErrSourceUnavailable.
+func (p *OSMProvider) query(ctx context.Context, query string) (*overpassResponse, error) {
+	start := int(p.current.Load())
+	kind := terminal.ErrSourceUnavailable
+	var errs []error
+	for i := range p.urls {
+		idx := (start + i) % len(p.urls)
+		data, err := p.queryURL(ctx, p.urls[idx], query)
+		if err == nil {
+			p.current.Store(int64(idx))
+			return data, nil
+		}
+		if errors.Is(err, errBadResponse) {
+			kind = terminal.ErrSourceBadData
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break // время обновления вышло, остальные зеркала не пробуем
+		}
 	}
-	if err := json.Unmarshal(body, &osmData); err != nil {
-		return nil, err
+	return nil, terminal.NewSourceError(p.Name(), kind, errors.Join(errs...))
+}
+
+// errBadResponse - сервер ответил 200, но ответ не разобрать
+var errBadResponse = errors.New("неразборчивый ответ")
+
+// queryURL - один запрос к серверу Overpass
+func (p *OSMProvider) queryURL(ctx context.Context, url, query string) (*overpassResponse, error) {
+	fail := func(err error) error {
+		return fmt.Errorf("%s: %w", url, err)
 	}
 
-	var atms []terminal.ATM
-	for _, el := range osmData.Elements {
-		bank := "Unknown"
-		// Пытаемся определить банк по тегам
-		if val, ok := el.Tags["brand"]; ok {
-			bank = val
-		} else if val, ok := el.Tags["operator"]; ok {
-			bank = val
-		} else if val, ok := el.Tags["name"]; ok {
-			bank = val
-		}
+	form := neturl.Values{"data": {query}}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fail(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-		name := bank + " ATM"
-		if val, ok := el.Tags["name"]; ok {
-			name = val
-		}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fail(err)
+	}
+	defer resp.Body.Close()
+
+	// При перегрузке (429, 504) Overpass отвечает HTML-страницей - разбирать ее незачем
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return nil, fail(fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet))))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fail(err)
+	}
 
-		// Мы БОЛЬШЕ НЕ пропускаем Forte. Мы берем всех.
-		atms = append(atms, terminal.ATM{
-			ID: int(el.ID), Name: name, Lat: el.Lat, Lng: el.Lon, Bank: bank,
-		})
+	var data overpassResponse
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fail(fmt.Errorf("%w: %w", errBadResponse, err))
+	}
+	// Ответ, обрезанный по таймауту или памяти, нельзя выдавать за всю сеть города
+	if strings.Contains(data.Remark, "runtime error") {
+		return nil, fail(errors.New(data.Remark))
 	}
-	return atms, nil
+	return &data, nil
+}
+
+// overpassBBox - bbox в формате Overpass: (south,west,north,east)
+func overpassBBox(b config.BBox) string {
+	return fmt.Sprintf("%g,%g,%g,%g", b.MinLat, b.MinLng, b.MaxLat, b.MaxLng)
 }
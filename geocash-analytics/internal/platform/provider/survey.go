@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"geocash/internal/config"
+	"geocash/internal/domain/brand"
+	"geocash/internal/domain/city"
+	"geocash/internal/domain/terminal"
+	"geocash/internal/geo"
+)
+
+// surveyColumns - обязательные колонки файла обхода
+var surveyColumns = []string{"bank", "name", "lat", "lng"}
+
+// SurveySource - банкоматы из CSV ручного обхода (bank,name,lat,lng).
+// Файл читается при каждом обновлении: его правят руками между обходами.
+type SurveySource struct {
+	path   string
+	brands *brand.Dictionary
+}
+
+func NewSurveySource(path string, brands *brand.Dictionary) *SurveySource {
+	return &SurveySource{path: path, brands: brands}
+}
+
+func (s *SurveySource) Name() string { return config.ATMSourceSurvey }
+
+// FetchATMs - банкоматы из файла в границах города
+func (s *SurveySource) FetchATMs(ctx context.Context, c city.City) ([]terminal.ATM, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, terminal.NewSourceError(s.Name(), terminal.ErrSourceUnavailable, err)
+	}
+	defer f.Close()
+
+	// Префикс ID - путь как в конфиге: у файлов из разных каталогов имя может совпадать
+	atms, err := readSurvey(f, s.Name()+":"+s.path, c.Bounds(), s.brands)
+	if err != nil {
+		return nil, terminal.NewSourceError(s.Name(), terminal.ErrSourceBadData, fmt.Errorf("%s: %w", s.path, err))
+	}
+	return atms, nil
+}
+
+// readSurvey читает банкоматы; ID - idPrefix:номер строки (источников обхода может быть несколько)
+func readSurvey(r io.Reader, idPrefix string, bounds geo.Bounds, brands *brand.Dictionary) ([]terminal.ATM, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("пустой файл")
+	}
+	if err != nil {
+		return nil, err
+	}
+	idx := make(map[string]int)
+	for i, h := range header {
+		idx[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, col := range surveyColumns {
+		if _, ok := idx[col]; !ok {
+			return nil, fmt.Errorf("нет колонки %s (ожидается %s)", col, strings.Join(surveyColumns, ","))
+		}
+	}
+
+	var atms []terminal.ATM
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return atms, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		lat, errLat := strconv.ParseFloat(strings.TrimSpace(row[idx["lat"]]), 64)
+		lng, errLng := strconv.ParseFloat(strings.TrimSpace(row[idx["lng"]]), 64)
+		if errLat != nil || errLng != nil {
+			return nil, fmt.Errorf("строка %d: некорректные координаты %q, %q", line, row[idx["lat"]], row[idx["lng"]])
+		}
+		if !bounds.Contains(geo.Point{Lat: lat, Lng: lng}) {
+			continue // другой город
+		}
+
+		bank := strings.TrimSpace(row[idx["bank"]])
+		name := strings.TrimSpace(row[idx["name"]])
+		if name == "" {
+			name = bank + " ATM"
+		}
+		atm := terminal.ATM{ID: idPrefix + ":" + strconv.Itoa(line), Name: name, Lat: lat, Lng: lng, Bank: bank}
+		if m, ok := brands.MatchName(bank, name); ok {
+			atm.Bank, atm.BankID = m.Name, m.BankID
+		}
+		atms = append(atms, atm)
+	}
+}
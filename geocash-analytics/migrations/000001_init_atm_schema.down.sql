@@ -1,9 +1,13 @@
 -- Удаляем таблицы в обратном порядке из-за внешних ключей (Foreign Keys)
+DROP VIEW IF EXISTS view_expansion_recommendations;
+DROP VIEW IF EXISTS view_dashboard_map;
 DROP TABLE IF EXISTS efficiency_reports;
 DROP TABLE IF EXISTS maintenance_logs;
+DROP TABLE IF EXISTS client_complaints;
 DROP TABLE IF EXISTS cash_levels;
 DROP TABLE IF EXISTS daily_stats;
+DROP TABLE IF EXISTS geo_traffic_zones;
 DROP TABLE IF EXISTS terminals;
 
 -- Опционально: отключаем PostGIS (если он больше не нужен другим таблицам)
--- DROP EXTENSION IF EXISTS postgis;
\ No newline at end of file
+-- DROP EXTENSION IF EXISTS postgis;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_maintenance_terminal_time;
+DROP INDEX IF EXISTS idx_complaints_terminal;
+DROP INDEX IF EXISTS idx_cash_levels_terminal_time;
+
+ALTER TABLE cash_levels DROP COLUMN IF EXISTS currency;
+ALTER TABLE cash_levels DROP COLUMN IF EXISTS cassette_type;
@@ -0,0 +1,7 @@
+-- Кассеты: у одного терминала несколько кассет (выдача и прием)
+ALTER TABLE cash_levels ADD COLUMN IF NOT EXISTS cassette_type VARCHAR(20) NOT NULL DEFAULT 'Cash-Out'; -- Cash-Out / Cash-In
+ALTER TABLE cash_levels ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'KZT';
+
+CREATE INDEX IF NOT EXISTS idx_cash_levels_terminal_time ON cash_levels (terminal_id, cassette_type, check_time DESC);
+CREATE INDEX IF NOT EXISTS idx_complaints_terminal ON client_complaints (terminal_id, status);
+CREATE INDEX IF NOT EXISTS idx_maintenance_terminal_time ON maintenance_logs (terminal_id, start_time);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS terminal_service_history;
+DROP TABLE IF EXISTS terminal_loadings;
+DROP TABLE IF EXISTS terminal_transactions;
@@ -0,0 +1,39 @@
+-- Операционные данные терминалов (выгрузки процессинга и инкассации).
+-- Все таблицы ссылаются на terminals.terminal_id, как и остальная схема.
+
+-- 1. Транзакции
+CREATE TABLE IF NOT EXISTS terminal_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id VARCHAR(64) UNIQUE NOT NULL, -- ID транзакции в процессинге
+    terminal_id VARCHAR(50) REFERENCES terminals(terminal_id) ON DELETE CASCADE,
+    transaction_time TIMESTAMP NOT NULL,
+    operation_type VARCHAR(20) NOT NULL DEFAULT 'WITHDRAWAL', -- WITHDRAWAL / DEPOSIT
+    amount NUMERIC(15, 2) NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL DEFAULT 'KZT',
+    status VARCHAR(20) NOT NULL DEFAULT 'COMPLETED' -- COMPLETED / FAILED / REVERSED
+);
+
+-- 2. Загрузки (остаток наличности на момент замера)
+CREATE TABLE IF NOT EXISTS terminal_loadings (
+    id BIGSERIAL PRIMARY KEY,
+    terminal_id VARCHAR(50) REFERENCES terminals(terminal_id) ON DELETE CASCADE,
+    record_time TIMESTAMP NOT NULL,
+    current_balance NUMERIC(15, 2) NOT NULL,
+    max_capacity NUMERIC(15, 2) NOT NULL,
+    UNIQUE(terminal_id, record_time)
+);
+
+-- 3. История обслуживания
+CREATE TABLE IF NOT EXISTS terminal_service_history (
+    id BIGSERIAL PRIMARY KEY,
+    terminal_id VARCHAR(50) REFERENCES terminals(terminal_id) ON DELETE CASCADE,
+    service_date TIMESTAMP NOT NULL,
+    service_type VARCHAR(50) NOT NULL, -- ENCASHMENT, REPAIR, CLEANING...
+    is_critical BOOLEAN NOT NULL DEFAULT FALSE,
+    description TEXT,
+    UNIQUE(terminal_id, service_date, service_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_terminal_time ON terminal_transactions (terminal_id, transaction_time);
+CREATE INDEX IF NOT EXISTS idx_loadings_terminal_time ON terminal_loadings (terminal_id, record_time DESC);
+CREATE INDEX IF NOT EXISTS idx_service_terminal_date ON terminal_service_history (terminal_id, service_date);
@@ -0,0 +1,22 @@
+-- История импортов: какие файлы и с каким результатом загружались.
+-- Повторный импорт определяется по хэшу содержимого, а не по имени файла.
+CREATE TABLE IF NOT EXISTS import_runs (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL DEFAULT 'traffic',
+    file_path TEXT NOT NULL,
+    content_hash CHAR(64) NOT NULL, -- SHA-256 содержимого файла
+    status VARCHAR(20) NOT NULL DEFAULT 'RUNNING', -- RUNNING / SUCCESS / FAILED
+    forced BOOLEAN NOT NULL DEFAULT FALSE,
+    rows_read INT NOT NULL DEFAULT 0,
+    rows_rejected INT NOT NULL DEFAULT 0,
+    rows_loaded INT NOT NULL DEFAULT 0,
+    rows_matched INT NOT NULL DEFAULT 0,
+    zones_updated INT NOT NULL DEFAULT 0,
+    rejects_file TEXT,
+    error TEXT,
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_runs_hash ON import_runs (kind, content_hash, status);
+CREATE INDEX IF NOT EXISTS idx_import_runs_started ON import_runs (started_at DESC);
@@ -0,0 +1,8 @@
+DROP FUNCTION IF EXISTS traffic_slice(DOUBLE PRECISION[], DOUBLE PRECISION, DOUBLE PRECISION[], DOUBLE PRECISION, TEXT, INT, INT);
+DROP FUNCTION IF EXISTS traffic_hours(DOUBLE PRECISION[], DOUBLE PRECISION, INT, INT);
+
+ALTER TABLE geo_traffic_zones
+    DROP COLUMN IF EXISTS weekday_traffic,
+    DROP COLUMN IF EXISTS weekend_traffic,
+    DROP COLUMN IF EXISTS weekday_hourly,
+    DROP COLUMN IF EXISTS weekend_hourly;
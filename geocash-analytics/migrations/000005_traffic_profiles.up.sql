@@ -0,0 +1,30 @@
+-- Профили трафика зон: будни/выходные и распределение по часам.
+-- *_hourly - 24 значения (часы 0..23), сумма по часам = суточному трафику.
+ALTER TABLE geo_traffic_zones
+    ADD COLUMN IF NOT EXISTS weekday_traffic DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS weekend_traffic DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS weekday_hourly DOUBLE PRECISION[],
+    ADD COLUMN IF NOT EXISTS weekend_hourly DOUBLE PRECISION[];
+
+-- Трафик за часы [from_hour, to_hour). Без профиля - пропорционально длине интервала.
+CREATE OR REPLACE FUNCTION traffic_hours(hourly DOUBLE PRECISION[], daily DOUBLE PRECISION, from_hour INT, to_hour INT)
+RETURNS DOUBLE PRECISION AS $$
+    SELECT CASE
+        WHEN hourly IS NULL THEN COALESCE(daily, 0) * (to_hour - from_hour) / 24.0
+        ELSE (SELECT COALESCE(SUM(v), 0) FROM unnest(hourly[from_hour + 1 : to_hour]) v)
+    END
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- Трафик зоны в срезе: day_type = weekday | weekend | all (средняя неделя 5+2)
+CREATE OR REPLACE FUNCTION traffic_slice(
+    weekday_hourly DOUBLE PRECISION[], weekday_traffic DOUBLE PRECISION,
+    weekend_hourly DOUBLE PRECISION[], weekend_traffic DOUBLE PRECISION,
+    day_type TEXT, from_hour INT, to_hour INT
+) RETURNS DOUBLE PRECISION AS $$
+    SELECT CASE day_type
+        WHEN 'weekday' THEN traffic_hours(weekday_hourly, weekday_traffic, from_hour, to_hour)
+        WHEN 'weekend' THEN traffic_hours(weekend_hourly, weekend_traffic, from_hour, to_hour)
+        ELSE (5 * traffic_hours(weekday_hourly, weekday_traffic, from_hour, to_hour)
+            + 2 * traffic_hours(weekend_hourly, weekend_traffic, from_hour, to_hour)) / 7
+    END
+$$ LANGUAGE SQL IMMUTABLE;
@@ -0,0 +1,18 @@
+DROP VIEW IF EXISTS view_expansion_recommendations;
+
+CREATE VIEW view_expansion_recommendations AS
+SELECT
+    z.zone_name,
+    z.traffic_score,
+    z.avg_pedestrians_daily,
+    z.area_polygon
+FROM geo_traffic_zones z
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM terminals t
+    WHERE ST_Contains(z.area_polygon, t.location)
+)
+AND z.traffic_score > 80;
+
+COMMENT ON COLUMN geo_traffic_zones.traffic_score IS NULL;
+ALTER TABLE geo_traffic_zones DROP COLUMN IF EXISTS traffic_raw;
@@ -0,0 +1,23 @@
+-- traffic_raw - сырой трафик зоны: сумма (будний трафик сегмента * длина сегмента внутри зоны, м).
+-- traffic_score (1-100) теперь считается из traffic_raw нормализацией по зонам импорта
+-- (прошлый трафик городов из файла сбрасывается), поэтому порог > 80 во
+-- view_expansion_recommendations не зависит от размера импорта.
+ALTER TABLE geo_traffic_zones ADD COLUMN IF NOT EXISTS traffic_raw DOUBLE PRECISION;
+
+COMMENT ON COLUMN geo_traffic_zones.traffic_raw IS 'Сумма traffic * длина сегмента внутри зоны (м)';
+COMMENT ON COLUMN geo_traffic_zones.traffic_score IS 'Нормализованный трафик 1-100 (traffic.score_method)';
+
+CREATE OR REPLACE VIEW view_expansion_recommendations AS
+SELECT
+    z.zone_name,
+    z.traffic_score,
+    z.avg_pedestrians_daily,
+    z.area_polygon,
+    z.traffic_raw
+FROM geo_traffic_zones z
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM terminals t
+    WHERE ST_Contains(z.area_polygon, t.location)
+)
+AND z.traffic_score > 80; -- Только горячие зоны (верхние 20% при score_method: percentile)
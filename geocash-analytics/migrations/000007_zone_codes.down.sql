@@ -0,0 +1,4 @@
+DELETE FROM geo_traffic_zones WHERE source_data = 'GRID';
+
+DROP INDEX IF EXISTS idx_zones_code;
+ALTER TABLE geo_traffic_zones DROP COLUMN IF EXISTS zone_code;
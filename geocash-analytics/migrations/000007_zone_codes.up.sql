@@ -0,0 +1,7 @@
+-- zone_code - стабильный код ячейки гексагональной сетки (<город>-hex-r012c034,
+-- например astana-hex-r012c034; id города - до 50 символов, как city_id).
+-- Зоны из сетки создаются командой `geocash grid generate` (source_data = 'GRID'),
+-- ручные зоны остаются с пустым кодом.
+ALTER TABLE geo_traffic_zones ADD COLUMN IF NOT EXISTS zone_code VARCHAR(80);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_zones_code ON geo_traffic_zones (zone_code);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS osm_network_changes;
+DROP TABLE IF EXISTS osm_snapshot_atms;
+DROP TABLE IF EXISTS osm_snapshots;
@@ -0,0 +1,42 @@
+-- Снимки источников банкоматов: ответ OSM сохраняется целиком, если сеть изменилась
+-- с прошлого снимка; банкоматы в снимке идентифицируются OSM id (node/123, way/456).
+-- Время - TIMESTAMPTZ: период в запросе изменений задается в часовом поясе города.
+CREATE TABLE IF NOT EXISTS osm_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    city_id VARCHAR(50) NOT NULL,
+    source VARCHAR(20) NOT NULL, -- osm, survey, ...
+    atm_count INT NOT NULL DEFAULT 0,
+    taken_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_osm_snapshots_city ON osm_snapshots (city_id, source, taken_at DESC);
+
+CREATE TABLE IF NOT EXISTS osm_snapshot_atms (
+    snapshot_id BIGINT NOT NULL REFERENCES osm_snapshots(id) ON DELETE CASCADE,
+    osm_id VARCHAR(32) NOT NULL,
+    bank_id VARCHAR(50), -- id из справочника brands, NULL - банк не опознан
+    bank TEXT,
+    name TEXT,
+    location GEOMETRY(Point, 4326) NOT NULL,
+    PRIMARY KEY (snapshot_id, osm_id)
+);
+
+-- Изменения сети: разница снимка с предыдущим снимком того же города и источника.
+-- Старые снимки удаляются по сроку хранения, а история изменений остается.
+CREATE TABLE IF NOT EXISTS osm_network_changes (
+    id BIGSERIAL PRIMARY KEY,
+    snapshot_id BIGINT REFERENCES osm_snapshots(id) ON DELETE SET NULL,
+    prev_snapshot_id BIGINT REFERENCES osm_snapshots(id) ON DELETE SET NULL,
+    city_id VARCHAR(50) NOT NULL,
+    change VARCHAR(10) NOT NULL CHECK (change IN ('added', 'removed', 'moved')),
+    osm_id VARCHAR(32) NOT NULL,
+    bank_id VARCHAR(50),
+    bank TEXT,
+    name TEXT,
+    location GEOMETRY(Point, 4326) NOT NULL, -- для removed - последнее известное место
+    prev_location GEOMETRY(Point, 4326), -- только для moved
+    moved_m DOUBLE PRECISION,
+    detected_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_osm_changes_city ON osm_network_changes (city_id, detected_at);
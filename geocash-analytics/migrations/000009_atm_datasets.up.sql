@@ -0,0 +1,7 @@
+-- Последний удачный набор банкоматов каждого города (после слияния источников):
+-- после рестарта карта показывает его, пока источники не ответят
+CREATE TABLE IF NOT EXISTS atm_datasets (
+    city_id VARCHAR(50) PRIMARY KEY,
+    updated_at TIMESTAMP NOT NULL, -- UTC
+    data JSONB NOT NULL            -- terminal.Dataset
+);
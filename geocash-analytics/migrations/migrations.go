@@ -0,0 +1,10 @@
+// Package migrations встраивает SQL-миграции в бинарник,
+// чтобы образу не нужен был ни каталог migrations/, ни внешний migrate CLI
+package migrations
+
+import "embed"
+
+// FS - файлы вида 000001_name.up.sql / 000001_name.down.sql
+//
+//go:embed *.sql
+var FS embed.FS